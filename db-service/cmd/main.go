@@ -1,14 +1,21 @@
 package main
 
 import (
+	"awesomeProject22/db-service/internal/auth"
 	"awesomeProject22/db-service/internal/cache"
 	"awesomeProject22/db-service/internal/controller"
+	"awesomeProject22/db-service/internal/delivery/handler"
+	"awesomeProject22/db-service/internal/graphql"
 	"awesomeProject22/db-service/internal/kafka"
+	"awesomeProject22/db-service/internal/mail"
+	"awesomeProject22/db-service/internal/oidc"
 	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/service"
 	"context"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -22,6 +29,77 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration in %s: %s", key, err.Error())
+	}
+	return duration
+}
+
+// getEnvTime reads an RFC 3339 time; unset means the zero time.
+func getEnvTime(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Fatalf("Invalid time in %s: %s", key, err.Error())
+	}
+	return parsed
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid number in %s: %s", key, err.Error())
+	}
+	return number
+}
+
+// getOIDCProviders reads OIDC_PROVIDERS (comma separated names) and, for each
+// name, OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL,
+// _ROLE_CLAIM and _ROLE_MAP ("claim=role,claim=role").
+func getOIDCProviders() []oidc.ProviderConfig {
+	var providers []oidc.ProviderConfig
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		roleMapping := map[string]string{}
+		for _, pair := range strings.Split(os.Getenv(prefix+"ROLE_MAP"), ",") {
+			if claim, role, found := strings.Cut(pair, "="); found {
+				roleMapping[strings.TrimSpace(claim)] = strings.TrimSpace(role)
+			}
+		}
+
+		providers = append(providers, oidc.ProviderConfig{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  getEnvOrDefault(prefix+"REDIRECT_URL", "http://localhost:8080/api/auth/oidc/"+name+"/callback"),
+			RoleClaim:    os.Getenv(prefix + "ROLE_CLAIM"),
+			RoleMapping:  roleMapping,
+		})
+	}
+
+	return providers
+}
+
 func main() {
 	dbConfig := repository.Config{
 		Host:     getEnvOrDefault("DB_HOST", "db"),
@@ -45,6 +123,99 @@ func main() {
 		GroupID: getEnvOrDefault("KAFKA_GROUP_ID", "library-service"),
 	}
 
+	tokenConfig := auth.TokenConfig{
+		Secret: getEnvOrDefault("AUTH_TOKEN_SECRET", "library-dev-secret"),
+		TTL:    getEnvDuration("AUTH_TOKEN_TTL", 24*time.Hour),
+	}
+
+	hasher, err := auth.NewPasswordHasher(auth.PasswordConfig{
+		Algorithm: getEnvOrDefault("PASSWORD_HASH_ALGORITHM", auth.AlgorithmArgon2id),
+		Argon2: auth.Argon2Params{
+			Memory:      uint32(getEnvInt("ARGON2_MEMORY_KIB", 64*1024)),
+			Iterations:  uint32(getEnvInt("ARGON2_ITERATIONS", 3)),
+			Parallelism: uint8(getEnvInt("ARGON2_PARALLELISM", 2)),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		BcryptCost: getEnvInt("BCRYPT_COST", 10),
+	})
+	if err != nil {
+		log.Fatalf("Invalid password hashing configuration: %s", err.Error())
+	}
+
+	loginGuardConfig := service.LoginGuardConfig{
+		MaxUserAttempts: getEnvInt("LOGIN_MAX_USER_ATTEMPTS", 5),
+		MaxIPAttempts:   getEnvInt("LOGIN_MAX_IP_ATTEMPTS", 50),
+		BaseBackoff:     getEnvDuration("LOGIN_BASE_BACKOFF", time.Second),
+		MaxBackoff:      getEnvDuration("LOGIN_MAX_BACKOFF", time.Minute),
+		LockoutDuration: getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		AttemptWindow:   getEnvDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+	}
+
+	accountConfig := service.AccountConfig{
+		PasswordResetTTL:     getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		EmailVerificationTTL: getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		BaseURL:              getEnvOrDefault("APP_BASE_URL", "http://localhost:8080"),
+	}
+
+	twoFactorConfig := service.TwoFactorConfig{
+		Issuer:           getEnvOrDefault("TOTP_ISSUER", "Library"),
+		RequireForAdmins: getEnvOrDefault("REQUIRE_ADMIN_2FA", "false") == "true",
+		ChallengeTTL:     getEnvDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+	}
+
+	auditConfig := service.AuditConfig{
+		HashChain: getEnvOrDefault("AUDIT_HASH_CHAIN", "true") == "true",
+	}
+
+	impersonationConfig := service.ImpersonationConfig{
+		TTL: getEnvDuration("IMPERSONATION_TTL", 15*time.Minute),
+	}
+
+	loginMonitorConfig := service.LoginMonitorConfig{
+		NotifySuspicious: getEnvOrDefault("NOTIFY_SUSPICIOUS_LOGIN", "false") == "true",
+	}
+
+	catalogCacheConfig := handler.CacheConfig{
+		Public:       getEnvOrDefault("CATALOG_CACHE_PUBLIC", "true") == "true",
+		MaxAge:       getEnvDuration("CATALOG_CACHE_MAX_AGE", time.Minute),
+		SharedMaxAge: getEnvDuration("CATALOG_CACHE_SHARED_MAX_AGE", 5*time.Minute),
+	}
+
+	idempotencyConfig := service.IdempotencyConfig{
+		TTL:         getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		LockTTL:     getEnvDuration("IDEMPOTENCY_LOCK_TTL", time.Minute),
+		WaitTimeout: getEnvDuration("IDEMPOTENCY_WAIT_TIMEOUT", 10*time.Second),
+	}
+
+	graphqlConfig := handler.GraphQLConfig{
+		MaxDepth:      getEnvInt("GRAPHQL_MAX_DEPTH", graphql.DefaultMaxDepth),
+		MaxComplexity: getEnvInt("GRAPHQL_MAX_COMPLEXITY", graphql.DefaultMaxComplexity),
+	}
+
+	apiVersions := map[string]handler.VersionPolicy{
+		handler.APIVersion1: {
+			DeprecatedAt: getEnvTime("API_V1_DEPRECATED_AT"),
+			Sunset:       getEnvTime("API_V1_SUNSET"),
+		},
+	}
+
+	var mailSender mail.IMailSender
+	switch driver := getEnvOrDefault("MAIL_DRIVER", "log"); driver {
+	case "smtp":
+		mailSender = mail.NewSMTPSender(mail.SMTPConfig{
+			Host:     getEnvOrDefault("SMTP_HOST", "localhost"),
+			Port:     getEnvOrDefault("SMTP_PORT", "25"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     getEnvOrDefault("MAIL_FROM", "library@localhost"),
+		})
+	case "log":
+		mailSender = mail.NewLogSender(os.Getenv("MAIL_LOG_PATH"))
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q", driver)
+	}
+
 	db, err := repository.NewPostgresDB(dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to initialize db: %s", err.Error())
@@ -67,9 +238,23 @@ func main() {
 	log.Println("Successfully connected to Kafka as producer")
 
 	ctrl := controller.NewController(controller.ControllerOptions{
-		DB:          db,
-		RedisClient: redisClient,
-		KafkaClient: kafkaClient,
+		DB:            db,
+		RedisClient:   redisClient,
+		KafkaClient:   kafkaClient,
+		TokenConfig:   tokenConfig,
+		Hasher:        hasher,
+		LoginGuard:    loginGuardConfig,
+		MailSender:    mailSender,
+		Account:       accountConfig,
+		TwoFactor:     twoFactorConfig,
+		Audit:         auditConfig,
+		Impersonation: impersonationConfig,
+		LoginMonitor:  loginMonitorConfig,
+		OIDC:          getOIDCProviders(),
+		CatalogCache:  catalogCacheConfig,
+		APIVersions:   apiVersions,
+		Idempotency:   idempotencyConfig,
+		GraphQL:       graphqlConfig,
 	})
 
 	srv := ctrl.GetServer()
@@ -0,0 +1,252 @@
+// Command stub-idp is a minimal OpenID Connect provider for local development
+// and manual testing of the SSO login. It signs ID tokens with a key generated
+// at startup and approves every authorization request without a login page.
+//
+// Claims can be chosen per request by adding sub, email, name, username and
+// groups (comma separated) to the authorization URL.
+//
+//	STUB_IDP_ISSUER=http://localhost:9000 go run ./db-service/cmd/stub-idp
+//
+// Point the service at it with:
+//
+//	OIDC_PROVIDERS=stub
+//	OIDC_STUB_ISSUER=http://localhost:9000
+//	OIDC_STUB_CLIENT_ID=library
+//	OIDC_STUB_ROLE_CLAIM=groups
+//	OIDC_STUB_ROLE_MAP=staff=librarian
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyID = "stub-key"
+
+type authorization struct {
+	ClientID      string
+	RedirectURI   string
+	CodeChallenge string
+	Nonce         string
+	Claims        map[string]interface{}
+	ExpiresAt     time.Time
+}
+
+type stubProvider struct {
+	issuer string
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+func main() {
+	issuer := strings.TrimSuffix(getEnvOrDefault("STUB_IDP_ISSUER", "http://localhost:9000"), "/")
+	port := getEnvOrDefault("STUB_IDP_PORT", "9000")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %s", err.Error())
+	}
+
+	provider := &stubProvider{
+		issuer: issuer,
+		key:    key,
+		codes:  map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/jwks", provider.jwks)
+	mux.HandleFunc("/authorize", provider.authorize)
+	mux.HandleFunc("/token", provider.token)
+
+	log.Printf("Stub identity provider %s listening on port %s", issuer, port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		log.Fatalf("Stub identity provider stopped: %s", err.Error())
+	}
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func (p *stubProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *stubProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": keyID,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *stubProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if redirectURI == "" || query.Get("response_type") != "code" {
+		http.Error(w, "redirect_uri and response_type=code are required", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	subject := getOrDefault(query, "sub", "stub-user")
+	claims := map[string]interface{}{
+		"sub":                subject,
+		"email":              getOrDefault(query, "email", subject+"@example.org"),
+		"email_verified":     query.Get("email_verified") != "false",
+		"name":               getOrDefault(query, "name", "Stub User"),
+		"preferred_username": getOrDefault(query, "username", subject),
+	}
+	if groups := query.Get("groups"); groups != "" {
+		claims["groups"] = strings.Split(groups, ",")
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authorization{
+		ClientID:      query.Get("client_id"),
+		RedirectURI:   redirectURI,
+		CodeChallenge: query.Get("code_challenge"),
+		Nonce:         query.Get("nonce"),
+		Claims:        claims,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := target.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	target.RawQuery = params.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (p *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok || time.Now().After(auth.ExpiresAt) ||
+		auth.RedirectURI != r.PostForm.Get("redirect_uri") || auth.ClientID != r.PostForm.Get("client_id") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifierHash[:]) != auth.CodeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := auth.Claims
+	claims["iss"] = p.issuer
+	claims["aud"] = auth.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(5 * time.Minute).Unix()
+	if auth.Nonce != "" {
+		claims["nonce"] = auth.Nonce
+	}
+
+	idToken, err := p.sign(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (p *stubProvider) sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func getOrDefault(query url.Values, key, defaultValue string) string {
+	if value := query.Get(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func randomString() string {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		log.Fatalf("Failed to generate random value: %s", err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Principal is either a logged-in user or an API key. API key principals have
+// no user and are limited to the permissions the key was scoped to. During
+// impersonation UserID is the impersonated user and ImpersonatorID the
+// administrator behind the session.
+type Principal struct {
+	UserID         uuid.UUID
+	APIKeyID       *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	Permissions    []string
+}
+
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != nil
+}
+
+func (p *Principal) IsImpersonated() bool {
+	return p.ImpersonatorID != nil
+}
+
+// ActorID is the person actually responsible for what the principal does.
+func (p *Principal) ActorID() uuid.UUID {
+	if p.ImpersonatorID != nil {
+		return *p.ImpersonatorID
+	}
+	return p.UserID
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
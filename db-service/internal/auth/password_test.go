@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+// argon2Reference is the hash of "password" with the salt "somesalt" from the
+// argon2 reference implementation.
+const argon2Reference = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestHasher(t *testing.T, cfg PasswordConfig) IPasswordHasher {
+	t.Helper()
+
+	hasher, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("error creating hasher: %v", err)
+	}
+	return hasher
+}
+
+func TestNewPasswordHasherRejectsConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  PasswordConfig
+	}{
+		{"unknown algorithm", PasswordConfig{Algorithm: "md5"}},
+		{"argon2id without memory", PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Iterations: 1, Parallelism: 1}}},
+		{"argon2id without parallelism", PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Memory: 64, Iterations: 1}}},
+		{"bcrypt cost too low", PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost - 1}},
+		{"bcrypt cost too high", PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MaxCost + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPasswordHasher(tt.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPasswordHashAndVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    PasswordConfig
+		prefix string
+	}{
+		{"argon2id", PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params}, "$argon2id$v=19$m=64,t=1,p=1$"},
+		{"bcrypt", PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}, "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := newTestHasher(t, tt.cfg)
+
+			encoded, err := hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("error hashing: %v", err)
+			}
+			if !strings.HasPrefix(encoded, tt.prefix) {
+				t.Errorf("hash %s does not start with %s", encoded, tt.prefix)
+			}
+
+			if ok, err := hasher.Verify(encoded, "correct horse"); !ok || err != nil {
+				t.Errorf("Verify(correct) = (%t, %v), want (true, nil)", ok, err)
+			}
+			if ok, err := hasher.Verify(encoded, "wrong horse"); ok || err != nil {
+				t.Errorf("Verify(wrong) = (%t, %v), want (false, nil)", ok, err)
+			}
+		})
+	}
+}
+
+func TestPasswordVerifyFormats(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+	hasher := newTestHasher(t, PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+
+	tests := []struct {
+		name    string
+		encoded string
+		want    bool
+		wantErr error
+	}{
+		{"argon2id reference", argon2Reference, true, nil},
+		{"legacy bcrypt", string(legacy), true, nil},
+		{"2y bcrypt", "$2y$" + strings.TrimPrefix(string(legacy), "$2a$"), true, nil},
+		{"argon2i", strings.Replace(argon2Reference, "$argon2id$", "$argon2i$", 1), false, ErrUnknownHashFormat},
+		{"argon2 version 16", strings.Replace(argon2Reference, "v=19", "v=16", 1), false, ErrUnknownHashFormat},
+		{"missing field", strings.TrimSuffix(argon2Reference, "$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"), false, ErrUnknownHashFormat},
+		{"bad parameters", strings.Replace(argon2Reference, "m=65536,t=2,p=1", "m=65536", 1), false, ErrUnknownHashFormat},
+		{"bad salt", strings.Replace(argon2Reference, "c29tZXNhbHQ", "!!", 1), false, ErrUnknownHashFormat},
+		{"plain text", "password", false, ErrUnknownHashFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := hasher.Verify(tt.encoded, "password")
+			if ok != tt.want {
+				t.Errorf("Verify = %t, want %t", ok, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeArgon2(t *testing.T) {
+	params, salt, key, err := decodeArgon2(argon2Reference)
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+
+	want := Argon2Params{Memory: 65536, Iterations: 2, Parallelism: 1, SaltLength: 8, KeyLength: 32}
+	if params != want {
+		t.Errorf("params = %+v, want %+v", params, want)
+	}
+	if string(salt) != "somesalt" {
+		t.Errorf("salt = %q, want %q", salt, "somesalt")
+	}
+	if encoded := encodeArgon2(params, salt, key); encoded != argon2Reference {
+		t.Errorf("encodeArgon2 = %s, want %s", encoded, argon2Reference)
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	bcrypt4, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+	bcrypt5, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost+1)
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+
+	argon2Config := PasswordConfig{Algorithm: AlgorithmArgon2id,
+		Argon2: Argon2Params{Memory: 65536, Iterations: 2, Parallelism: 1, SaltLength: 8, KeyLength: 32}}
+	stronger := func(change func(*Argon2Params)) PasswordConfig {
+		cfg := argon2Config
+		change(&cfg.Argon2)
+		return cfg
+	}
+	bcryptConfig := PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1}
+
+	tests := []struct {
+		name    string
+		cfg     PasswordConfig
+		encoded string
+		want    bool
+	}{
+		{"argon2id with current parameters", argon2Config, argon2Reference, false},
+		{"argon2id below memory", stronger(func(p *Argon2Params) { p.Memory *= 2 }), argon2Reference, true},
+		{"argon2id below iterations", stronger(func(p *Argon2Params) { p.Iterations++ }), argon2Reference, true},
+		{"argon2id below parallelism", stronger(func(p *Argon2Params) { p.Parallelism++ }), argon2Reference, true},
+		{"argon2id with short salt", stronger(func(p *Argon2Params) { p.SaltLength = 16 }), argon2Reference, true},
+		{"argon2id with short key", stronger(func(p *Argon2Params) { p.KeyLength = 64 }), argon2Reference, true},
+		{"argon2id above configuration", stronger(func(p *Argon2Params) { p.Memory /= 2 }), argon2Reference, false},
+		{"bcrypt under argon2id", argon2Config, string(bcrypt5), true},
+		{"broken argon2id", argon2Config, "$argon2id$v=19$broken", true},
+		{"bcrypt at cost", bcryptConfig, string(bcrypt5), false},
+		{"bcrypt below cost", bcryptConfig, string(bcrypt4), true},
+		{"argon2id under bcrypt", bcryptConfig, argon2Reference, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTestHasher(t, tt.cfg).NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
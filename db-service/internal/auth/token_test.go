@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	manager := NewTokenManager(TokenConfig{Secret: "secret", TTL: time.Hour})
+	userID := uuid.New()
+	actorID := uuid.New()
+
+	tests := []struct {
+		name    string
+		issue   func() (string, error)
+		purpose string
+		actor   *uuid.UUID
+	}{
+		{
+			name:  "access token",
+			issue: func() (string, error) { return manager.Issue(userID) },
+		},
+		{
+			name:    "challenge token",
+			issue:   func() (string, error) { return manager.IssueChallenge(userID, PurposeTwoFactorLogin, time.Minute) },
+			purpose: PurposeTwoFactorLogin,
+		},
+		{
+			name:  "impersonation token",
+			issue: func() (string, error) { return manager.IssueImpersonation(userID, actorID, time.Minute) },
+			actor: &actorID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.issue()
+			if err != nil {
+				t.Fatalf("error issuing token: %v", err)
+			}
+
+			claims, err := manager.Parse(token)
+			if err != nil {
+				t.Fatalf("error parsing token: %v", err)
+			}
+			if claims.Subject != userID {
+				t.Errorf("subject = %s, want %s", claims.Subject, userID)
+			}
+			if claims.Purpose != tt.purpose {
+				t.Errorf("purpose = %q, want %q", claims.Purpose, tt.purpose)
+			}
+			switch {
+			case tt.actor == nil && claims.Actor != nil:
+				t.Errorf("unexpected actor %s", claims.Actor.Subject)
+			case tt.actor != nil && (claims.Actor == nil || claims.Actor.Subject != *tt.actor):
+				t.Errorf("actor = %v, want %s", claims.Actor, *tt.actor)
+			}
+		})
+	}
+}
+
+func TestTokenActorClaim(t *testing.T) {
+	manager := NewTokenManager(TokenConfig{Secret: "secret", TTL: time.Hour})
+	actorID := uuid.New()
+
+	token, err := manager.IssueImpersonation(uuid.New(), actorID, time.Minute)
+	if err != nil {
+		t.Fatalf("error issuing token: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	if err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+	if want := `"act":{"sub":"` + actorID.String() + `"}`; !strings.Contains(string(payload), want) {
+		t.Errorf("payload %s does not contain %s", payload, want)
+	}
+}
+
+func TestTokenParseRejects(t *testing.T) {
+	manager := NewTokenManager(TokenConfig{Secret: "secret", TTL: time.Hour})
+	valid, err := manager.Issue(uuid.New())
+	if err != nil {
+		t.Fatalf("error issuing token: %v", err)
+	}
+	expired, err := manager.IssueChallenge(uuid.New(), PurposeTwoFactorEnroll, -time.Second)
+	if err != nil {
+		t.Fatalf("error issuing token: %v", err)
+	}
+	other, err := NewTokenManager(TokenConfig{Secret: "other", TTL: time.Hour}).Issue(uuid.New())
+	if err != nil {
+		t.Fatalf("error issuing token: %v", err)
+	}
+
+	parts := strings.Split(valid, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + uuid.New().String() + `","exp":9999999999}`))
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"two parts", parts[0] + "." + parts[1]},
+		{"expired", expired},
+		{"other secret", other},
+		{"changed payload", parts[0] + "." + forged + "." + parts[2]},
+		{"changed header", noneHeader + "." + parts[1] + "." + parts[2]},
+		{"unsigned", parts[0] + "." + parts[1] + "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.Parse(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
@@ -18,8 +18,15 @@ type RedisConfig struct {
 type IRedisClient interface {
 	Close() error
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 	Get(ctx context.Context, key string) (string, error)
-	Delete(ctx context.Context, key string) error
+	GetDel(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, keys ...string) error
+	Incr(ctx context.Context, key string) (int64, error)
+	IncrByExpire(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error)
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Scan(ctx context.Context, match string) ([]string, error)
 }
 
 type RedisClient struct {
@@ -59,6 +66,16 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 	return nil
 }
 
+// SetNX only sets the key when it does not exist yet and reports whether it
+// did.
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	set, err := r.client.SetNX(ctx, key, value, expiration).Result()
+	if err != nil {
+		return false, fmt.Errorf("error setting value in redis: %w", err)
+	}
+	return set, nil
+}
+
 func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	val, err := r.client.Get(ctx, key).Result()
 	if err != nil {
@@ -70,9 +87,75 @@ func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	return val, nil
 }
 
-func (r *RedisClient) Delete(ctx context.Context, key string) error {
-	if err := r.client.Del(ctx, key).Err(); err != nil {
-		return fmt.Errorf("error deleting key from redis: %w", err)
+// GetDel reads and removes the key in one step, so only one caller can
+// consume the value.
+func (r *RedisClient) GetDel(ctx context.Context, key string) (string, error) {
+	val, err := r.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", err
+		}
+		return "", fmt.Errorf("error getting and deleting value from redis: %w", err)
+	}
+	return val, nil
+}
+
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("error deleting keys from redis: %w", err)
 	}
 	return nil
 }
+
+func (r *RedisClient) Incr(ctx context.Context, key string) (int64, error) {
+	val, err := r.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error incrementing value in redis: %w", err)
+	}
+	return val, nil
+}
+
+// IncrByExpire changes the counter and starts its expiration if it has none
+// in one MULTI/EXEC, so a counter never outlives its window and the returned
+// value is the one the caller decides on.
+func (r *RedisClient) IncrByExpire(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(ctx, key, delta)
+		pipe.ExpireNX(ctx, key, expiration)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error incrementing value in redis: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+func (r *RedisClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	if err := r.client.Expire(ctx, key, expiration).Err(); err != nil {
+		return fmt.Errorf("error setting expiration in redis: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	val, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error getting ttl from redis: %w", err)
+	}
+	return val, nil
+}
+
+// Scan lists the keys matching the pattern without blocking the server the
+// way KEYS does. It is meant for rare maintenance work.
+func (r *RedisClient) Scan(ctx context.Context, match string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, match, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning keys in redis: %w", err)
+	}
+	return keys, nil
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"awesomeProject22/db-service/internal/auth"
 	"awesomeProject22/db-service/internal/cache"
 	"awesomeProject22/db-service/internal/delivery/handler"
 	"awesomeProject22/db-service/internal/kafka"
+	"awesomeProject22/db-service/internal/mail"
+	"awesomeProject22/db-service/internal/oidc"
 	"awesomeProject22/db-service/internal/repository"
 	"awesomeProject22/db-service/internal/service"
 	"awesomeProject22/db-service/pkg"
@@ -12,61 +15,146 @@ import (
 )
 
 type ControllerOptions struct {
-	DB          *pgxpool.Pool
-	RedisClient cache.IRedisClient
-	KafkaClient kafka.IKafkaClient
+	DB            *pgxpool.Pool
+	RedisClient   cache.IRedisClient
+	KafkaClient   kafka.IKafkaClient
+	TokenConfig   auth.TokenConfig
+	Hasher        auth.IPasswordHasher
+	LoginGuard    service.LoginGuardConfig
+	MailSender    mail.IMailSender
+	Account       service.AccountConfig
+	TwoFactor     service.TwoFactorConfig
+	Audit         service.AuditConfig
+	Impersonation service.ImpersonationConfig
+	LoginMonitor  service.LoginMonitorConfig
+	OIDC          []oidc.ProviderConfig
+	CatalogCache  handler.CacheConfig
+	APIVersions   map[string]handler.VersionPolicy
+	Idempotency   service.IdempotencyConfig
+	GraphQL       handler.GraphQLConfig
 }
 
 type Controller struct {
-	db            *pgxpool.Pool
-	redisClient   cache.IRedisClient
-	kafkaClient   kafka.IKafkaClient
-	bookService   service.IBookService
-	userService   service.IUserService
-	server        *pkg.Server
-	bookHandler   handler.IBookHandler
-	userHandler   handler.IUserHandler
-	eventProducer kafka.IEventProducer
+	db             *pgxpool.Pool
+	redisClient    cache.IRedisClient
+	kafkaClient    kafka.IKafkaClient
+	bookService    service.IBookService
+	userService    service.IUserService
+	authzService   service.IAuthzService
+	accountService service.IAccountService
+	server         *pkg.Server
+	bookHandler    handler.IBookHandler
+	userHandler    handler.IUserHandler
+	roleHandler    handler.IRoleHandler
+	accountHandler handler.IAccountHandler
+	eventProducer  kafka.IEventProducer
 }
 
 func NewController(opts ControllerOptions) *Controller {
 	var bookRepo repository.IBookRepository
 	var userRepo repository.IUserRepository
+	var roleRepo repository.IRoleRepository
 
 	baseBookRepo := repository.NewBookRepository(opts.DB)
 	baseUserRepo := repository.UserRepo(opts.DB)
+	baseRoleRepo := repository.NewRoleRepository(opts.DB)
 
 	if opts.RedisClient != nil {
 		bookRepo = repository.CachedBookRepo(baseBookRepo, opts.RedisClient)
 		userRepo = repository.CachedUserRepo(baseUserRepo, opts.RedisClient)
+		roleRepo = repository.CachedRoleRepo(baseRoleRepo, opts.RedisClient)
 	} else {
 		bookRepo = baseBookRepo
 		userRepo = baseUserRepo
+		roleRepo = baseRoleRepo
 	}
 
+	securityAuditRepo := repository.NewSecurityAuditRepository(opts.DB)
+	userTokenRepo := repository.NewUserTokenRepository(opts.DB)
+	twoFactorRepo := repository.NewTwoFactorRepository(opts.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(opts.DB)
+	identityRepo := repository.NewIdentityRepository(opts.DB)
+	privacyRepo := repository.NewPrivacyRepository(opts.DB)
+	auditRepo := repository.NewAuditRepository(opts.DB)
+	loginHistoryRepo := repository.NewLoginHistoryRepository(opts.DB)
+
 	eventProducer := kafka.NewEventProducer(opts.KafkaClient)
+	tokenManager := auth.NewTokenManager(opts.TokenConfig)
 
 	bookService := service.BookService(bookRepo, eventProducer)
-	userService := service.UserService(userRepo, eventProducer)
+	authzService := service.AuthzService(roleRepo, userRepo, securityAuditRepo)
+	var loginGuard service.ILoginGuard
+	if opts.RedisClient != nil {
+		loginGuard = service.NewLoginGuard(opts.RedisClient, opts.LoginGuard)
+	}
 
-	bookHandler := handler.NewBookHandler(bookService)
+	twoFactorService := service.TwoFactorService(twoFactorRepo, userRepo, authzService, securityAuditRepo, loginGuard,
+		opts.TwoFactor)
+	loginMonitorService := service.LoginMonitorService(loginHistoryRepo, authzService, securityAuditRepo, eventProducer,
+		opts.MailSender, opts.LoginMonitor)
+	userService := service.UserService(userRepo, eventProducer, tokenManager, opts.Hasher, authzService, securityAuditRepo, loginGuard,
+		twoFactorService, opts.TwoFactor, loginMonitorService)
+
+	apiKeyService := service.APIKeyService(apiKeyRepo, authzService)
+	var oidcProviders []oidc.IProvider
+	for _, providerConfig := range opts.OIDC {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(providerConfig, nil))
+	}
+	if len(oidcProviders) > 0 && opts.RedisClient == nil {
+		log.Println("OIDC login requires Redis for login state; identity providers are disabled")
+		oidcProviders = nil
+	}
+	oidcService := service.OIDCService(oidcProviders, opts.RedisClient, identityRepo, userRepo, userService, authzService,
+		twoFactorService)
+
+	accountService := service.AccountService(userRepo, userTokenRepo, securityAuditRepo, opts.MailSender, opts.Hasher,
+		loginGuard, opts.Account)
+	idempotencyService := service.IdempotencyService(opts.RedisClient, opts.Idempotency)
+	privacyService := service.PrivacyService(userRepo, privacyRepo, loginHistoryRepo, twoFactorRepo, authzService, securityAuditRepo, loginGuard,
+		idempotencyService, eventProducer)
+	auditService := service.AuditService(auditRepo, opts.Audit)
+	impersonationService := service.ImpersonationService(userRepo, tokenManager, authzService, securityAuditRepo,
+		opts.Impersonation)
+	usageService := service.APIUsageService(opts.RedisClient)
+
+	bookHandler := handler.NewBookHandler(bookService, opts.CatalogCache)
 	userHandler := handler.NewUserHandler(userService)
+	roleHandler := handler.NewRoleHandler(authzService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	twoFactorHandler := handler.NewTwoFactorHandler(userService, twoFactorService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	oidcHandler := handler.NewOIDCHandler(oidcService)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationService)
+	loginActivityHandler := handler.NewLoginActivityHandler(loginMonitorService)
+	apiUsageHandler := handler.NewAPIUsageHandler(usageService, opts.APIVersions)
+	docsHandler := handler.NewDocsHandler(opts.APIVersions)
+	graphqlHandler := handler.NewGraphQLHandler(bookService, userService, authzService, opts.GraphQL)
+	middleware := handler.NewMiddleware(tokenManager, userService, authzService, apiKeyService, auditService, usageService,
+		idempotencyService)
 
 	server := pkg.NewServer()
 
-	deliveryRouter := handler.NewRouter(bookHandler, userHandler)
+	deliveryRouter := handler.NewRouter(bookHandler, userHandler, roleHandler, accountHandler, twoFactorHandler,
+		apiKeyHandler, oidcHandler, privacyHandler, auditHandler, impersonationHandler, loginActivityHandler, apiUsageHandler,
+		docsHandler, graphqlHandler, opts.APIVersions, middleware)
 	deliveryRouter.RegisterRoutes(server.GetRouter())
 
 	return &Controller{
-		db:            opts.DB,
-		redisClient:   opts.RedisClient,
-		kafkaClient:   opts.KafkaClient,
-		bookService:   bookService,
-		userService:   userService,
-		server:        server,
-		bookHandler:   bookHandler,
-		userHandler:   userHandler,
-		eventProducer: eventProducer,
+		db:             opts.DB,
+		redisClient:    opts.RedisClient,
+		kafkaClient:    opts.KafkaClient,
+		bookService:    bookService,
+		userService:    userService,
+		authzService:   authzService,
+		accountService: accountService,
+		server:         server,
+		bookHandler:    bookHandler,
+		userHandler:    userHandler,
+		roleHandler:    roleHandler,
+		accountHandler: accountHandler,
+		eventProducer:  eventProducer,
 	}
 }
 
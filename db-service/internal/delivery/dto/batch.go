@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// BookBatchRequest lists the writes of a book batch. Create and update items
+// carry the book fields; update and delete items address the book by id and
+// version, where version 0 skips the check like If-Match: *.
+type BookBatchRequest struct {
+	Items []BookBatchItem `json:"items" validate:"required,max=1000"`
+}
+
+type BookBatchItem struct {
+	Op      string    `json:"op" validate:"required"`
+	ID      uuid.UUID `json:"id,omitempty"`
+	Version *int64    `json:"version,omitempty"`
+	Genre   string    `json:"genre,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Author  string    `json:"author,omitempty"`
+	Year    int       `json:"year,omitempty"`
+}
+
+// UserBatchRequest lists the writes of a user batch. Items follow the rules of
+// CreateUserRequest and UpdateUserRequest.
+type UserBatchRequest struct {
+	Items []UserBatchItem `json:"items" validate:"required,max=1000"`
+}
+
+type UserBatchItem struct {
+	Op       string    `json:"op" validate:"required"`
+	ID       uuid.UUID `json:"id,omitempty"`
+	Version  *int64    `json:"version,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	Password string    `json:"password,omitempty"`
+	IsAdmin  *bool     `json:"is_admin,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult reports one item of a committed batch. Version is the new row
+// version, left out for deletes.
+type BatchResult struct {
+	Index   int       `json:"index"`
+	Op      string    `json:"op"`
+	Status  int       `json:"status"`
+	ID      uuid.UUID `json:"id"`
+	Version int64     `json:"version,omitempty"`
+}
+
+func (i *BookBatchItem) ToDomain() *domain.Book {
+	return &domain.Book{
+		Genre:  i.Genre,
+		Name:   i.Name,
+		Author: i.Author,
+		Year:   i.Year,
+	}
+}
@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+type BookRequest struct {
+	Genre  string `json:"genre"`
+	Name   string `json:"name"`
+	Author string `json:"author"`
+	Year   int    `json:"year"`
+}
+
+type BookResponse struct {
+	ID     uuid.UUID `json:"id"`
+	Genre  string    `json:"genre"`
+	Name   string    `json:"name"`
+	Author string    `json:"author"`
+	Year   int       `json:"year"`
+}
+
+// BookResponseV2 is the book of API v2, which also exposes the version and the
+// time of the last change.
+type BookResponseV2 struct {
+	BookResponse
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type BookPage struct {
+	Items  []BookResponseV2 `json:"items"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+func (r *BookRequest) ToDomain(id uuid.UUID) *domain.Book {
+	return &domain.Book{
+		ID:     id,
+		Genre:  r.Genre,
+		Name:   r.Name,
+		Author: r.Author,
+		Year:   r.Year,
+	}
+}
+
+func ToBookResponse(book *domain.Book) BookResponse {
+	return BookResponse{
+		ID:     book.ID,
+		Genre:  book.Genre,
+		Name:   book.Name,
+		Author: book.Author,
+		Year:   book.Year,
+	}
+}
+
+func ToBookResponses(books []*domain.Book) []BookResponse {
+	responses := make([]BookResponse, 0, len(books))
+	for _, book := range books {
+		responses = append(responses, ToBookResponse(book))
+	}
+	return responses
+}
+
+func ToBookResponseV2(book *domain.Book) BookResponseV2 {
+	return BookResponseV2{
+		BookResponse: ToBookResponse(book),
+		Version:      book.Version,
+		UpdatedAt:    book.UpdatedAt,
+	}
+}
+
+// ToBookPage wraps one page of books read with limit and offset out of total.
+func ToBookPage(books []*domain.Book, total, limit, offset int) BookPage {
+	items := make([]BookResponseV2, 0, len(books))
+	for _, book := range books {
+		items = append(items, ToBookResponseV2(book))
+	}
+	return BookPage{Items: items, Total: total, Limit: limit, Offset: offset}
+}
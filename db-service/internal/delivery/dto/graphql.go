@@ -0,0 +1,19 @@
+package dto
+
+import "awesomeProject22/db-service/internal/graphql"
+
+// GraphQLRequest is the body of POST /graphql. GET requests carry the same
+// members as query parameters, with variables encoded as JSON.
+type GraphQLRequest struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse documents the result of a query. Data is left out when the
+// query was rejected before execution.
+type GraphQLResponse struct {
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Errors []graphql.Error        `json:"errors,omitempty"`
+}
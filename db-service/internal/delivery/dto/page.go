@@ -0,0 +1,6 @@
+package dto
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=64"`
+	Email    string `json:"email" validate:"required,max=255,email"`
+	Password string `json:"password" validate:"required,min=8,max=128"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+type UpdateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=64"`
+	Email    string `json:"email" validate:"required,max=255,email"`
+	Password string `json:"password,omitempty" validate:"omitempty,min=8,max=128"`
+	IsAdmin  *bool  `json:"is_admin,omitempty"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+type UserResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	IsAdmin       bool      `json:"is_admin"`
+	EmailVerified bool      `json:"email_verified"`
+}
+
+// UserResponseV2 is the user of API v2, which also exposes the version.
+type UserResponseV2 struct {
+	UserResponse
+	Version int64 `json:"version"`
+}
+
+type UserPage struct {
+	Items  []UserResponseV2 `json:"items"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+func (r *CreateUserRequest) ToDomain() *domain.User {
+	return &domain.User{
+		Username: r.Username,
+		Email:    r.Email,
+		IsAdmin:  r.IsAdmin,
+	}
+}
+
+// ApplyTo keeps the current admin flag when is_admin is left out, so a
+// replacement cannot drop privileges by accident.
+func (r *UpdateUserRequest) ApplyTo(user *domain.User) {
+	user.Username = r.Username
+	user.Email = r.Email
+	if r.IsAdmin != nil {
+		user.IsAdmin = *r.IsAdmin
+	}
+}
+
+func ToUserResponse(user *domain.User) UserResponse {
+	return UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		IsAdmin:       user.IsAdmin,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+func ToUserResponses(users []domain.User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, ToUserResponse(&users[i]))
+	}
+	return responses
+}
+
+func ToUserResponseV2(user *domain.User) UserResponseV2 {
+	return UserResponseV2{
+		UserResponse: ToUserResponse(user),
+		Version:      user.Version,
+	}
+}
+
+// ToUserPage wraps one page of users read with limit and offset out of total.
+func ToUserPage(users []domain.User, total, limit, offset int) UserPage {
+	items := make([]UserResponseV2, 0, len(users))
+	for i := range users {
+		items = append(items, ToUserResponseV2(&users[i]))
+	}
+	return UserPage{Items: items, Total: total, Limit: limit, Offset: offset}
+}
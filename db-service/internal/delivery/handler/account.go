@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/service"
+	"errors"
+	"net/http"
+)
+
+type IAccountHandler interface {
+	RequestPasswordReset(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	RequestEmailVerification(w http.ResponseWriter, r *http.Request)
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+}
+
+type AccountHandler struct {
+	accountService service.IAccountService
+}
+
+func NewAccountHandler(accountService service.IAccountService) IAccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+type emailInput struct {
+	Email string `json:"email" validate:"required,max=255,email"`
+}
+
+type passwordResetInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=128"`
+}
+
+type tokenInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (h *AccountHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var input emailInput
+
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.accountService.RequestPasswordReset(r.Context(), input.Email); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var input passwordResetInput
+
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), input.Token, input.Password); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AccountHandler) RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	var input emailInput
+
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.accountService.RequestEmailVerification(r.Context(), input.Email); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var input tokenInput
+
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), input.Token); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAccountError reports rejected reset and verification tokens as bad
+// input: the caller is not authenticating with them.
+func writeAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrTokenInvalid) {
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+	writeServiceError(w, r, err)
+}
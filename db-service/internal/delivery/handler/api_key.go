@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+type IAPIKeyHandler interface {
+	ListKeys(w http.ResponseWriter, r *http.Request)
+	CreateKey(w http.ResponseWriter, r *http.Request)
+	RotateKey(w http.ResponseWriter, r *http.Request)
+	RevokeKey(w http.ResponseWriter, r *http.Request)
+}
+
+type APIKeyHandler struct {
+	apiKeyService service.IAPIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.IAPIKeyService) IAPIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type apiKeyInput struct {
+	Name        string     `json:"name" validate:"required,max=255"`
+	Permissions []string   `json:"permissions" validate:"min=1"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type apiKeyResponse struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyService.GetAll(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var keyInput apiKeyInput
+
+	if err := decodeJSON(w, r, &keyInput); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	key, plainKey, err := h.apiKeyService.Create(r.Context(), keyInput.Name, keyInput.Permissions, keyInput.ExpiresAt)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(apiKeyResponse{APIKey: *key, Key: plainKey})
+}
+
+func (h *APIKeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid API key ID")
+		return
+	}
+
+	key, plainKey, err := h.apiKeyService.Rotate(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiKeyResponse{APIKey: *key, Key: plainKey})
+}
+
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultUsageDays = 30
+
+type IAPIUsageHandler interface {
+	GetUsage(w http.ResponseWriter, r *http.Request)
+}
+
+type APIUsageHandler struct {
+	usageService service.IAPIUsageService
+	versions     map[string]VersionPolicy
+}
+
+func NewAPIUsageHandler(usageService service.IAPIUsageService, versions map[string]VersionPolicy) IAPIUsageHandler {
+	return &APIUsageHandler{
+		usageService: usageService,
+		versions:     versions,
+	}
+}
+
+type apiUsageResponse struct {
+	domain.APIUsage
+	Deprecated   bool       `json:"deprecated"`
+	DeprecatedAt *time.Time `json:"deprecated_at,omitempty"`
+	Sunset       *time.Time `json:"sunset,omitempty"`
+}
+
+func (h *APIUsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	days := defaultUsageDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeBadRequest(w, r, "Invalid days")
+			return
+		}
+		days = parsed
+	}
+
+	usage, err := h.usageService.GetUsage(r.Context(), apiVersions, days)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response := make([]apiUsageResponse, 0, len(usage))
+	for _, versionUsage := range usage {
+		policy := h.versions[versionUsage.Version]
+		entry := apiUsageResponse{APIUsage: versionUsage, Deprecated: policy.Deprecated()}
+		if policy.Deprecated() {
+			entry.DeprecatedAt = &policy.DeprecatedAt
+		}
+		if !policy.Sunset.IsZero() {
+			entry.Sunset = &policy.Sunset
+		}
+		response = append(response, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
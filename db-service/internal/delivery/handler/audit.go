@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type IAuditHandler interface {
+	ListEntries(w http.ResponseWriter, r *http.Request)
+	VerifyChain(w http.ResponseWriter, r *http.Request)
+}
+
+type AuditHandler struct {
+	auditService service.IAuditService
+}
+
+func NewAuditHandler(auditService service.IAuditService) IAuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+func (h *AuditHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		RequestID:  query.Get("request_id"),
+	}
+
+	for name, target := range map[string]**uuid.UUID{"actor_id": &filter.ActorID, "on_behalf_of": &filter.OnBehalfOf} {
+		if value := query.Get(name); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				writeBadRequest(w, r, "Invalid "+name)
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeBadRequest(w, r, "Invalid "+name+", expected RFC 3339")
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				writeBadRequest(w, r, "Invalid "+name)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	entries, err := h.auditService.List(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *AuditHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	result, err := h.auditService.Verify(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/delivery/dto"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var batchStatus = map[string]int{
+	domain.BatchCreate: http.StatusCreated,
+	domain.BatchUpdate: http.StatusOK,
+	domain.BatchDelete: http.StatusNoContent,
+}
+
+// batchVersion requires update and delete items to state the version they
+// change, as If-Match does for single requests.
+func batchVersion(index int, op string, version *int64) (int64, error) {
+	if op != domain.BatchUpdate && op != domain.BatchDelete {
+		return 0, nil
+	}
+	if version == nil {
+		return 0, &service.ValidationError{Fields: []service.FieldError{{
+			Field:   fmt.Sprintf("items[%d].version", index),
+			Message: "is required, 0 skips the version check",
+		}}}
+	}
+	return *version, nil
+}
+
+// validateBatchItem applies the `validate` tags of v and names the failing
+// fields after the item.
+func validateBatchItem(index int, v interface{}) error {
+	err := service.Validate(v)
+	var validation *service.ValidationError
+	if !errors.As(err, &validation) {
+		return err
+	}
+	for i := range validation.Fields {
+		validation.Fields[i].Field = fmt.Sprintf("items[%d].%s", index, validation.Fields[i].Field)
+	}
+	return validation
+}
+
+func writeBatchResults(w http.ResponseWriter, results []service.BatchResult) {
+	response := dto.BatchResponse{Results: make([]dto.BatchResult, len(results))}
+	for i, result := range results {
+		response.Results[i] = dto.BatchResult{Index: i, Op: result.Op, Status: batchStatus[result.Op], ID: result.ID}
+		if result.Op != domain.BatchDelete {
+			response.Results[i].Version = result.Version
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"awesomeProject22/db-service/internal/delivery/dto"
 	"awesomeProject22/db-service/internal/domain"
 	"awesomeProject22/db-service/internal/service"
 	"encoding/json"
@@ -14,16 +15,20 @@ type IBookHandler interface {
 	GetBook(w http.ResponseWriter, r *http.Request)
 	CreateBook(w http.ResponseWriter, r *http.Request)
 	UpdateBook(w http.ResponseWriter, r *http.Request)
+	PatchBook(w http.ResponseWriter, r *http.Request)
 	DeleteBook(w http.ResponseWriter, r *http.Request)
+	BatchBooks(w http.ResponseWriter, r *http.Request)
 }
 
 type BookHandler struct {
 	bookService service.IBookService
+	cache       CacheConfig
 }
 
-func NewBookHandler(bookService service.IBookService) IBookHandler {
+func NewBookHandler(bookService service.IBookService, cache CacheConfig) IBookHandler {
 	return &BookHandler{
 		bookService: bookService,
+		cache:       cache,
 	}
 }
 
@@ -31,88 +36,204 @@ func (h *BookHandler) GetAllBooks(w http.ResponseWriter, r *http.Request) {
 	author := r.URL.Query().Get("author")
 	genre := r.URL.Query().Get("genre")
 
-	books, err := h.bookService.GetAll(r.Context(), author, genre)
+	// Read before the list so that a change in between makes the copy look
+	// older, never newer, than it is.
+	lastModified, err := h.bookService.LastModified(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
+		return
+	}
+
+	var response interface{}
+	if apiVersionFromContext(r.Context()) == APIVersion2 {
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		books, total, err := h.bookService.GetPage(r.Context(), author, genre, limit, offset)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		response = dto.ToBookPage(books, total, limit, offset)
+	} else {
+		books, err := h.bookService.GetAll(r.Context(), author, genre)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		response = dto.ToBookResponses(books)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if writeValidators(w, r, h.cache, contentETag(body), lastModified) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(books)
+	w.Write(append(body, '\n'))
 }
 
 func (h *BookHandler) GetBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid book ID")
 		return
 	}
 
 	book, err := h.bookService.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if writeValidators(w, r, h.cache, versionETag(book.Version), book.UpdatedAt) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(book)
+	json.NewEncoder(w).Encode(bookResponse(r, book))
 }
 
 func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
-	var book domain.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var bookInput dto.BookRequest
+	if err := decodeJSON(w, r, &bookInput); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	if err := h.bookService.Create(r.Context(), &book); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	book := bookInput.ToDomain(uuid.Nil)
+
+	if err := h.bookService.Create(r.Context(), book); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
+	setETag(w, book.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(book)
+	json.NewEncoder(w).Encode(bookResponse(r, book))
 }
 
 func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid book ID")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	var bookInput dto.BookRequest
+	if err := decodeJSON(w, r, &bookInput); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	book := bookInput.ToDomain(id)
+	book.Version = version
+
+	if err := h.bookService.Update(r.Context(), book); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, book.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookResponse(r, book))
+}
+
+func (h *BookHandler) PatchBook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid book ID")
 		return
 	}
 
-	var book domain.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	book.ID = id
+	mediaType, patch, err := readPatch(w, r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
 
-	if err := h.bookService.Update(r.Context(), &book); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	book, err := h.bookService.Patch(r.Context(), id, version, mediaType, patch)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
+	setETag(w, book.Version)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(book)
+	json.NewEncoder(w).Encode(bookResponse(r, book))
 }
 
 func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid book ID")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	if err := h.bookService.Delete(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.bookService.Delete(r.Context(), id, version); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *BookHandler) BatchBooks(w http.ResponseWriter, r *http.Request) {
+	var batchInput dto.BookBatchRequest
+
+	if err := decodeJSON(w, r, &batchInput); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	operations := make([]service.BookBatchOperation, len(batchInput.Items))
+	for i, item := range batchInput.Items {
+		version, err := batchVersion(i, item.Op, item.Version)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		operations[i] = service.BookBatchOperation{Op: item.Op, ID: item.ID, Version: version}
+		if item.Op != domain.BatchDelete {
+			operations[i].Book = item.ToDomain()
+		}
+	}
+
+	results, err := h.bookService.ApplyBatch(r.Context(), operations)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeBatchResults(w, results)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/service"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheConfig describes the Cache-Control header of cacheable responses.
+// Public responses may be stored by shared caches such as a CDN, which then
+// keep them for SharedMaxAge instead of MaxAge when it is set. A zero MaxAge
+// lets caches store a response but makes them revalidate it on every use.
+type CacheConfig struct {
+	Public       bool
+	MaxAge       time.Duration
+	SharedMaxAge time.Duration
+}
+
+func (c CacheConfig) header() string {
+	directives := []string{"private"}
+	if c.Public {
+		directives[0] = "public"
+	}
+
+	if c.MaxAge > 0 {
+		directives = append(directives, fmt.Sprintf("max-age=%d", int(c.MaxAge.Seconds())))
+	} else {
+		directives = append(directives, "no-cache")
+	}
+	if c.Public && c.SharedMaxAge > 0 {
+		directives = append(directives, fmt.Sprintf("s-maxage=%d", int(c.SharedMaxAge.Seconds())))
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+var errPreconditionRequired = errors.New("the If-Match header is required, send the ETag of the resource you are changing")
+
+// setETag exposes the row version as a strong entity tag.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", versionETag(version))
+}
+
+func versionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion returns the version a write is conditioned on. "*" matches
+// any version. Weak or unknown tags can never match the strong tags we issue.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errPreconditionRequired
+	}
+	if header == "*" {
+		return repository.AnyVersion, nil
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, fmt.Errorf("%w: If-Match %s does not match the current version", service.ErrPreconditionFailed, header)
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("%w: If-Match %s does not match the current version", service.ErrPreconditionFailed, header)
+	}
+	return version, nil
+}
+
+// contentETag derives a strong entity tag from a response body, for resources
+// such as lists that have no version of their own.
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return strconv.Quote(hex.EncodeToString(sum[:16]))
+}
+
+// writeValidators sets the cache headers of a successful GET and reports
+// whether the client's copy is still fresh, in which case a 304 has already
+// been written. If-None-Match takes precedence over If-Modified-Since. A zero
+// lastModified leaves out Last-Modified and ignores If-Modified-Since.
+func writeValidators(w http.ResponseWriter, r *http.Request, cache CacheConfig, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cache.header())
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if !etagListMatches(ifNoneMatch, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagListMatches applies the weak comparison If-None-Match calls for.
+func etagListMatches(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheConfigHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CacheConfig
+		want   string
+	}{
+		{"default", CacheConfig{}, "private, no-cache"},
+		{"private with max age", CacheConfig{MaxAge: time.Minute}, "private, max-age=60"},
+		{"public", CacheConfig{Public: true, MaxAge: time.Minute}, "public, max-age=60"},
+		{"public with shared max age", CacheConfig{Public: true, MaxAge: time.Minute, SharedMaxAge: time.Hour}, "public, max-age=60, s-maxage=3600"},
+		{"private ignores shared max age", CacheConfig{MaxAge: time.Minute, SharedMaxAge: time.Hour}, "private, max-age=60"},
+		{"public revalidated", CacheConfig{Public: true}, "public, no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.header(); got != tt.want {
+				t.Errorf("header() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteValidators(t *testing.T) {
+	etag := contentETag([]byte(`[{"id":1}]`))
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		lastModified time.Time
+		want         bool
+	}{
+		{"unconditional", nil, modified, false},
+		{"matching etag", map[string]string{"If-None-Match": etag}, modified, true},
+		{"weak matching etag", map[string]string{"If-None-Match": "W/" + etag}, modified, true},
+		{"etag in list", map[string]string{"If-None-Match": `"other", ` + etag}, modified, true},
+		{"any etag", map[string]string{"If-None-Match": "*"}, modified, true},
+		{"other etag", map[string]string{"If-None-Match": `"other"`}, modified, false},
+		{"etag wins over date", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, modified, false},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, modified, true},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, modified, false},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, modified, false},
+		{"date without last modified", map[string]string{"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/books", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+
+			if got := writeValidators(w, r, CacheConfig{MaxAge: time.Minute}, etag, tt.lastModified); got != tt.want {
+				t.Errorf("writeValidators = %t, want %t", got, tt.want)
+			}
+
+			status := w.Result().StatusCode
+			if tt.want && status != http.StatusNotModified {
+				t.Errorf("status = %d, want %d", status, http.StatusNotModified)
+			}
+			if !tt.want && w.Code != http.StatusOK {
+				t.Errorf("a status of %d was written for a fresh response", w.Code)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "private, max-age=60" {
+				t.Errorf("Cache-Control = %q", got)
+			}
+
+			wantLastModified := ""
+			if !tt.lastModified.IsZero() {
+				wantLastModified = "Wed, 01 May 2024 12:00:00 GMT"
+			}
+			if got := w.Header().Get("Last-Modified"); got != wantLastModified {
+				t.Errorf("Last-Modified = %q, want %q", got, wantLastModified)
+			}
+		})
+	}
+}
+
+func TestContentETag(t *testing.T) {
+	first := contentETag([]byte(`[{"id":1}]`))
+	if first != contentETag([]byte(`[{"id":1}]`)) {
+		t.Error("equal bodies got different ETags")
+	}
+	if first == contentETag([]byte(`[{"id":2}]`)) {
+		t.Error("different bodies got the same ETag")
+	}
+	if len(first) != 34 || first[0] != '"' || first[33] != '"' {
+		t.Errorf("ETag %s is not a quoted 32 digit tag", first)
+	}
+}
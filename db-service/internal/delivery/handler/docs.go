@@ -0,0 +1,43 @@
+package handler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+type IDocsHandler interface {
+	OpenAPISpec(w http.ResponseWriter, r *http.Request)
+	DocsUI(w http.ResponseWriter, r *http.Request)
+}
+
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler renders the OpenAPI document once; it only changes with the
+// code and the version policies.
+func NewDocsHandler(versions map[string]VersionPolicy) IDocsHandler {
+	spec, err := json.MarshalIndent(newAPIDocument(versions), "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("error rendering OpenAPI document: %v", err))
+	}
+
+	return &DocsHandler{
+		spec: spec,
+	}
+}
+
+func (h *DocsHandler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.spec)
+}
+
+func (h *DocsHandler) DocsUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
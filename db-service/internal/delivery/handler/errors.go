@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/requestctx"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	codeBadRequest       = "bad_request"
+	codeValidationFailed = "validation_failed"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeConflict         = "conflict"
+	codePrecondition     = "precondition_failed"
+	codePreconditionReq  = "precondition_required"
+	codeIdempotencyReuse = "idempotency_key_reused"
+	codePayloadTooLarge  = "payload_too_large"
+	codeUnsupportedMedia = "unsupported_media_type"
+	codeTooManyRequests  = "too_many_requests"
+	codeInternal         = "internal_error"
+)
+
+type errorResponse struct {
+	Code      string               `json:"code"`
+	Message   string               `json:"message"`
+	Details   []service.FieldError `json:"details,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeErrorResponse(w, r, status, errorResponse{Code: code, Message: message})
+}
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, response errorResponse) {
+	response.RequestID = requestctx.ClientInfoFromContext(r.Context()).RequestID
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeBadRequest(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusBadRequest, codeBadRequest, message)
+}
+
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusUnauthorized, codeUnauthorized, message)
+}
+
+func writeForbidden(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusForbidden, codeForbidden, message)
+}
+
+// writeServiceError derives the status from the error type so handlers never
+// have to guess it. Unexpected errors are logged and reported without their
+// details.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var validation *service.ValidationError
+	var throttled *service.LoginThrottledError
+
+	switch {
+	case errors.As(err, &validation):
+		writeErrorResponse(w, r, http.StatusUnprocessableEntity, errorResponse{
+			Code:    codeValidationFailed,
+			Message: "request validation failed",
+			Details: validation.Fields,
+		})
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		writeError(w, r, http.StatusUnprocessableEntity, codeIdempotencyReuse, err.Error())
+	case errors.Is(err, errBodyTooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, codePayloadTooLarge, err.Error())
+	case errors.Is(err, errUnsupportedMediaType):
+		writeError(w, r, http.StatusUnsupportedMediaType, codeUnsupportedMedia, err.Error())
+	case errors.As(err, &throttled):
+		w.Header().Set("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())+1))
+		writeError(w, r, http.StatusTooManyRequests, codeTooManyRequests, throttled.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		writeBadRequest(w, r, err.Error())
+	case errors.Is(err, service.ErrUnauthorized), errors.Is(err, auth.ErrInvalidToken):
+		writeUnauthorized(w, r, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		writeForbidden(w, r, err.Error())
+	case errors.Is(err, service.ErrNotFound), errors.Is(err, repository.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, codeNotFound, err.Error())
+	case errors.Is(err, service.ErrConflict), errors.Is(err, repository.ErrConflict):
+		writeError(w, r, http.StatusConflict, codeConflict, err.Error())
+	case errors.Is(err, errPreconditionRequired):
+		writeError(w, r, http.StatusPreconditionRequired, codePreconditionReq, err.Error())
+	case errors.Is(err, service.ErrPreconditionFailed), errors.Is(err, repository.ErrStaleVersion):
+		writeError(w, r, http.StatusPreconditionFailed, codePrecondition, err.Error())
+	default:
+		log.Printf("Internal error on %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/delivery/dto"
+	"awesomeProject22/db-service/internal/graphql"
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+const (
+	graphqlCodeUnauthenticated = "UNAUTHENTICATED"
+	graphqlCodeForbidden       = "FORBIDDEN"
+	graphqlCodeNotFound        = "NOT_FOUND"
+)
+
+type IGraphQLHandler interface {
+	Query(w http.ResponseWriter, r *http.Request)
+}
+
+// GraphQLConfig limits the queries a client may send. Zero values apply the
+// defaults of the graphql package.
+type GraphQLConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+type GraphQLHandler struct {
+	bookService  service.IBookService
+	userService  service.IUserService
+	authzService service.IAuthzService
+	config       GraphQLConfig
+	schema       *graphql.Schema
+}
+
+func NewGraphQLHandler(bookService service.IBookService, userService service.IUserService, authzService service.IAuthzService,
+	config GraphQLConfig) IGraphQLHandler {
+	h := &GraphQLHandler{
+		bookService:  bookService,
+		userService:  userService,
+		authzService: authzService,
+		config:       config,
+	}
+	h.schema = h.newSchema()
+	return h
+}
+
+// Query runs a GraphQL query sent as JSON with POST or as query parameters
+// with GET. Requests rejected before execution answer 400; once executed the
+// response is 200 and errors of single fields are listed next to the data.
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var request dto.GraphQLRequest
+	if err := readGraphQLRequest(w, r, &request); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphqlRequestKey{}, h.newGraphQLRequest(r.Context()))
+	response := graphql.Execute(ctx, graphql.Params{
+		Schema:        h.schema,
+		Query:         request.Query,
+		OperationName: request.OperationName,
+		Variables:     request.Variables,
+		MaxDepth:      h.config.MaxDepth,
+		MaxComplexity: h.config.MaxComplexity,
+		FormatError:   graphqlErrorFormatter(r),
+	})
+
+	status := http.StatusOK
+	if response.Data == nil {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func readGraphQLRequest(w http.ResponseWriter, r *http.Request, request *dto.GraphQLRequest) error {
+	if r.Method != http.MethodGet {
+		return decodeJSON(w, r, request)
+	}
+
+	query := r.URL.Query()
+	request.Query = query.Get("query")
+	request.OperationName = query.Get("operationName")
+	if variables := query.Get("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+			return fmt.Errorf("%w: variables must be a JSON object: %v", service.ErrInvalidInput, err)
+		}
+	}
+	return service.Validate(request)
+}
+
+// graphqlErrorFormatter gives field errors the codes clients know from other
+// GraphQL servers. Like writeServiceError it logs unexpected errors and hides
+// their details.
+func graphqlErrorFormatter(r *http.Request) graphql.ErrorFormatter {
+	return func(err error) *graphql.Error {
+		var gqlErr *graphql.Error
+		var validation *service.ValidationError
+
+		code := graphql.CodeInternal
+		message := err.Error()
+		switch {
+		case errors.As(err, &gqlErr):
+			return &graphql.Error{Message: gqlErr.Message, Extensions: gqlErr.Extensions}
+		case errors.As(err, &validation), errors.Is(err, service.ErrInvalidInput):
+			code = graphql.CodeBadUserInput
+		case errors.Is(err, service.ErrUnauthorized):
+			code = graphqlCodeUnauthenticated
+		case errors.Is(err, service.ErrForbidden):
+			code = graphqlCodeForbidden
+		case errors.Is(err, service.ErrNotFound), errors.Is(err, repository.ErrNotFound):
+			code = graphqlCodeNotFound
+		default:
+			log.Printf("Internal error on %s %s: %v", r.Method, r.URL.Path, err)
+			message = "internal server error"
+		}
+		return &graphql.Error{Message: message, Extensions: map[string]interface{}{"code": code}}
+	}
+}
@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/delivery/dto"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/graphql"
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+type graphqlRequestKey struct{}
+
+// graphqlRequest holds the loaders and permission checks of one request, so a
+// query reads each kind of record with one call per level instead of one per
+// parent.
+type graphqlRequest struct {
+	books         *graphql.Loader[uuid.UUID, *domain.Book]
+	booksByAuthor *graphql.Loader[string, []*domain.Book]
+	bookLoans     *graphql.Loader[uuid.UUID, []domain.UserBook]
+	users         *graphql.Loader[uuid.UUID, *domain.User]
+	userLoans     *graphql.Loader[uuid.UUID, []domain.UserBook]
+	permissions   map[string]error
+}
+
+func graphqlRequestFromContext(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlRequestKey{}).(*graphqlRequest)
+}
+
+func (h *GraphQLHandler) newGraphQLRequest(ctx context.Context) *graphqlRequest {
+	return &graphqlRequest{
+		books: graphql.NewLoader(ctx, func(ctx context.Context, ids []uuid.UUID) []graphql.Result[*domain.Book] {
+			books, err := h.bookService.GetByIDs(ctx, ids)
+			return loadResults(ids, err, func() map[uuid.UUID]*domain.Book {
+				byID := make(map[uuid.UUID]*domain.Book, len(books))
+				for _, book := range books {
+					byID[book.ID] = book
+				}
+				return byID
+			})
+		}),
+		booksByAuthor: graphql.NewLoader(ctx, func(ctx context.Context, authors []string) []graphql.Result[[]*domain.Book] {
+			books, err := h.bookService.GetByAuthors(ctx, authors)
+			return loadResults(authors, err, func() map[string][]*domain.Book {
+				byAuthor := map[string][]*domain.Book{}
+				for _, book := range books {
+					byAuthor[book.Author] = append(byAuthor[book.Author], book)
+				}
+				return byAuthor
+			})
+		}),
+		bookLoans: graphql.NewLoader(ctx, func(ctx context.Context, ids []uuid.UUID) []graphql.Result[[]domain.UserBook] {
+			loans, err := h.bookService.GetLoans(ctx, ids)
+			return loadResults(ids, err, func() map[uuid.UUID][]domain.UserBook {
+				byBook := map[uuid.UUID][]domain.UserBook{}
+				for _, loan := range loans {
+					byBook[loan.BookID] = append(byBook[loan.BookID], loan)
+				}
+				return byBook
+			})
+		}),
+		users: graphql.NewLoader(ctx, func(ctx context.Context, ids []uuid.UUID) []graphql.Result[*domain.User] {
+			users, err := h.userService.GetByIDs(ctx, ids)
+			return loadResults(ids, err, func() map[uuid.UUID]*domain.User {
+				byID := make(map[uuid.UUID]*domain.User, len(users))
+				for i := range users {
+					byID[users[i].ID] = &users[i]
+				}
+				return byID
+			})
+		}),
+		userLoans: graphql.NewLoader(ctx, func(ctx context.Context, ids []uuid.UUID) []graphql.Result[[]domain.UserBook] {
+			loans, err := h.userService.GetLoans(ctx, ids)
+			return loadResults(ids, err, func() map[uuid.UUID][]domain.UserBook {
+				byUser := map[uuid.UUID][]domain.UserBook{}
+				for _, loan := range loans {
+					byUser[loan.UserID] = append(byUser[loan.UserID], loan)
+				}
+				return byUser
+			})
+		}),
+		permissions: map[string]error{},
+	}
+}
+
+// loadResults orders the records of a batch after its keys. A failed batch
+// fails every key.
+func loadResults[K comparable, V any](keys []K, err error, index func() map[K]V) []graphql.Result[V] {
+	results := make([]graphql.Result[V], len(keys))
+	if err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+	byKey := index()
+	for i, key := range keys {
+		results[i].Value = byKey[key]
+	}
+	return results
+}
+
+// authorize applies RequirePermission to a field. The outcome is kept for the
+// request, as the field may resolve once per parent.
+func (h *GraphQLHandler) authorize(ctx context.Context, permission string) error {
+	request := graphqlRequestFromContext(ctx)
+	if err, ok := request.permissions[permission]; ok {
+		return err
+	}
+
+	var err error
+	if _, ok := auth.PrincipalFromContext(ctx); !ok {
+		err = fmt.Errorf("%w: authentication required", service.ErrUnauthorized)
+	} else if allowed, authzErr := h.authzService.Authorize(ctx, permission); authzErr != nil {
+		err = fmt.Errorf("error checking permission %s: %w", permission, authzErr)
+	} else if !allowed {
+		err = fmt.Errorf("%w: missing permission %s", service.ErrForbidden, permission)
+	}
+	request.permissions[permission] = err
+	return err
+}
+
+func (h *GraphQLHandler) newSchema() *graphql.Schema {
+	nonNull := func(typ graphql.Type) graphql.Type { return &graphql.NonNull{OfType: typ} }
+	listOf := func(typ graphql.Type) graphql.Type {
+		return &graphql.NonNull{OfType: &graphql.List{OfType: nonNull(typ)}}
+	}
+	pageArgs := map[string]*graphql.Argument{
+		"limit":  {Type: graphql.Int, Default: dto.DefaultPageLimit},
+		"offset": {Type: graphql.Int, Default: 0},
+	}
+	pageSize := func(args map[string]interface{}) int {
+		return intArg(args, "limit", dto.DefaultPageLimit)
+	}
+
+	book := &graphql.Object{Name: "Book"}
+	author := &graphql.Object{Name: "Author"}
+	loan := &graphql.Object{Name: "Loan"}
+	user := &graphql.Object{Name: "User"}
+
+	book.Fields = map[string]*graphql.Field{
+		"id":        bookField(nonNull(graphql.ID), func(b *domain.Book) interface{} { return b.ID }),
+		"genre":     bookField(nonNull(graphql.String), func(b *domain.Book) interface{} { return b.Genre }),
+		"name":      bookField(nonNull(graphql.String), func(b *domain.Book) interface{} { return b.Name }),
+		"year":      bookField(nonNull(graphql.Int), func(b *domain.Book) interface{} { return b.Year }),
+		"version":   bookField(nonNull(graphql.Int), func(b *domain.Book) interface{} { return b.Version }),
+		"updatedAt": bookField(nonNull(graphql.String), func(b *domain.Book) interface{} { return b.UpdatedAt.Format(time.RFC3339) }),
+		"author":    bookField(nonNull(author), func(b *domain.Book) interface{} { return b.Author }),
+		"loans": {
+			Type: listOf(loan),
+			Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				// Loans tell who borrowed a book, so they are as protected as
+				// the list of users.
+				if err := h.authorize(ctx, domain.PermissionUsersRead); err != nil {
+					return nil, err
+				}
+				return graphqlRequestFromContext(ctx).bookLoans.Load(source.(*domain.Book).ID), nil
+			},
+		},
+	}
+
+	author.Fields = map[string]*graphql.Field{
+		"name": {
+			Type: nonNull(graphql.String),
+			Resolve: func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return source.(string), nil
+			},
+		},
+		"books": {
+			Type: listOf(book),
+			Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return graphqlRequestFromContext(ctx).booksByAuthor.Load(source.(string)), nil
+			},
+		},
+	}
+
+	loan.Fields = map[string]*graphql.Field{
+		"user": {
+			Type: user,
+			Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return graphqlRequestFromContext(ctx).users.Load(source.(domain.UserBook).UserID), nil
+			},
+		},
+		"book": {
+			Type: book,
+			Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return graphqlRequestFromContext(ctx).books.Load(source.(domain.UserBook).BookID), nil
+			},
+		},
+	}
+
+	user.Fields = map[string]*graphql.Field{
+		"id":            userField(nonNull(graphql.ID), func(u *domain.User) interface{} { return u.ID }),
+		"username":      userField(nonNull(graphql.String), func(u *domain.User) interface{} { return u.Username }),
+		"email":         userField(nonNull(graphql.String), func(u *domain.User) interface{} { return u.Email }),
+		"isAdmin":       userField(nonNull(graphql.Boolean), func(u *domain.User) interface{} { return u.IsAdmin }),
+		"emailVerified": userField(nonNull(graphql.Boolean), func(u *domain.User) interface{} { return u.EmailVerified }),
+		"version":       userField(nonNull(graphql.Int), func(u *domain.User) interface{} { return u.Version }),
+		"loans": {
+			Type: listOf(loan),
+			Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return graphqlRequestFromContext(ctx).userLoans.Load(source.(*domain.User).ID), nil
+			},
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"book": {
+			Type: book,
+			Args: map[string]*graphql.Argument{"id": {Type: nonNull(graphql.ID)}},
+			Resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := graphqlID(args["id"])
+				if err != nil {
+					return nil, err
+				}
+				return graphqlRequestFromContext(ctx).books.Load(id), nil
+			},
+		},
+		"books": {
+			Type: listOf(book),
+			Args: map[string]*graphql.Argument{
+				"author": {Type: graphql.String},
+				"genre":  {Type: graphql.String},
+				"limit":  pageArgs["limit"],
+				"offset": pageArgs["offset"],
+			},
+			ListSize: pageSize,
+			Resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				limit, offset, err := graphqlPage(args)
+				if err != nil {
+					return nil, err
+				}
+				author, _ := args["author"].(string)
+				genre, _ := args["genre"].(string)
+				books, _, err := h.bookService.GetPage(ctx, author, genre, limit, offset)
+				return books, err
+			},
+		},
+		"user": {
+			Type: user,
+			Args: map[string]*graphql.Argument{"id": {Type: nonNull(graphql.ID)}},
+			Resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := graphqlID(args["id"])
+				if err != nil {
+					return nil, err
+				}
+				found, err := h.userService.GetByID(ctx, id)
+				if errors.Is(err, repository.ErrNotFound) {
+					return nil, nil
+				}
+				return found, err
+			},
+		},
+		"users": {
+			Type:     listOf(user),
+			Args:     pageArgs,
+			ListSize: pageSize,
+			Resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				if err := h.authorize(ctx, domain.PermissionUsersRead); err != nil {
+					return nil, err
+				}
+				limit, offset, err := graphqlPage(args)
+				if err != nil {
+					return nil, err
+				}
+				users, _, err := h.userService.GetPage(ctx, limit, offset)
+				if err != nil {
+					return nil, err
+				}
+				page := make([]*domain.User, len(users))
+				for i := range users {
+					page[i] = &users[i]
+				}
+				return page, nil
+			},
+		},
+	}}
+
+	return &graphql.Schema{Query: query}
+}
+
+func bookField(typ graphql.Type, value func(*domain.Book) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			return value(source.(*domain.Book)), nil
+		},
+	}
+}
+
+func userField(typ graphql.Type, value func(*domain.User) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			return value(source.(*domain.User)), nil
+		},
+	}
+}
+
+func graphqlID(value interface{}) (uuid.UUID, error) {
+	id, err := uuid.Parse(value.(string))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid ID %q", service.ErrInvalidInput, value)
+	}
+	return id, nil
+}
+
+// graphqlPage checks the limit and offset arguments like pageParams.
+func graphqlPage(args map[string]interface{}) (int, int, error) {
+	limit, offset := intArg(args, "limit", dto.DefaultPageLimit), intArg(args, "offset", 0)
+	if limit < 1 || limit > dto.MaxPageLimit {
+		return 0, 0, fmt.Errorf("%w: limit must be between 1 and %d", service.ErrInvalidInput, dto.MaxPageLimit)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("%w: offset must not be negative", service.ErrInvalidInput)
+	}
+	return limit, offset, nil
+}
+
+// intArg reads an optional Int argument, which is nil when null was passed.
+func intArg(args map[string]interface{}, name string, fallback int) int {
+	if value, ok := args[name].(int); ok {
+		return value
+	}
+	return fallback
+}
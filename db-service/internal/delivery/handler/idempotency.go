@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/requestctx"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyAnonymousScope = "anonymous"
+)
+
+// responseRecorder passes the response on and keeps a copy for replays.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(body []byte) (int, error) {
+	r.body.Write(body)
+	return r.ResponseWriter.Write(body)
+}
+
+// idempotencyScope keeps the keys of different callers and routes apart, so
+// one client can never replay the response of another. Anonymous callers are
+// told apart by their address and the request itself: only a client that
+// sends the very same request from the same address gets the replay.
+func idempotencyScope(r *http.Request, fingerprint string) string {
+	caller := fmt.Sprintf("%s:%s:%s", idempotencyAnonymousScope, requestctx.ClientInfoFromContext(r.Context()).IP,
+		fingerprint)
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		if principal.IsAPIKey() {
+			caller = "key:" + principal.APIKeyID.String()
+		} else {
+			caller = "user:" + principal.UserID.String()
+		}
+	}
+	return fmt.Sprintf("%s:%s %s", caller, r.Method, r.URL.Path)
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s\n", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Idempotent makes retries of a request that carries an Idempotency-Key safe:
+// the first request runs, later ones with the same key and body replay its
+// response, and a different body under the same key is rejected. Responses
+// that a retry may not get again, such as server errors, failed
+// authentication or throttling, release the key so the request can be retried.
+func (m *Middleware) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || m.idempotencyService == nil {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeBadRequest(w, r, fmt.Sprintf("%s must not exceed %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		if err != nil {
+			writeServiceError(w, r, decodeError(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(r, body)
+		scope := idempotencyScope(r, fingerprint)
+		record, err := m.idempotencyService.Begin(r.Context(), scope, key, fingerprint)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if record != nil {
+			replayResponse(w, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		// The response is already sent; a cancelled client must not leave the
+		// key locked.
+		ctx := context.WithoutCancel(r.Context())
+		if !replayableStatus(recorder.status) {
+			m.idempotencyService.Abandon(ctx, scope, key)
+			return
+		}
+		m.idempotencyService.Complete(ctx, scope, key, &domain.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      recorder.status,
+			Header:      replayableHeader(recorder.Header()),
+			Body:        recorder.body.Bytes(),
+		})
+	}
+}
+
+// replayableStatus reports whether a response is final for its request: a
+// success, or a client error the same body always gets again.
+func replayableStatus(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		return true
+	}
+	return status >= 200 && status < 300
+}
+
+// replayableHeader leaves out the headers every response sets for itself.
+func replayableHeader(header http.Header) map[string][]string {
+	replayable := header.Clone()
+	replayable.Del("X-Request-ID")
+	return replayable
+}
+
+func replayResponse(w http.ResponseWriter, record *domain.IdempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
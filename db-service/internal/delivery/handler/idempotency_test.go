@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/requestctx"
+	"github.com/google/uuid"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIdempotencyScope(t *testing.T) {
+	userID := uuid.New()
+	request := func(ip string, principal *auth.Principal) string {
+		r := httptest.NewRequest("POST", "/api/users", nil)
+		ctx := requestctx.WithClientInfo(r.Context(), requestctx.ClientInfo{IP: ip})
+		if principal != nil {
+			ctx = auth.WithPrincipal(ctx, principal)
+		}
+		return idempotencyScope(r.WithContext(ctx), "fingerprint")
+	}
+
+	tests := []struct {
+		name      string
+		a, b      string
+		wantEqual bool
+	}{
+		{"anonymous callers on different addresses", request("10.0.0.1", nil), request("10.0.0.2", nil), false},
+		{"anonymous caller retrying", request("10.0.0.1", nil), request("10.0.0.1", nil), true},
+		{"user on different addresses", request("10.0.0.1", &auth.Principal{UserID: userID}),
+			request("10.0.0.2", &auth.Principal{UserID: userID}), true},
+		{"user and anonymous caller", request("10.0.0.1", &auth.Principal{UserID: userID}), request("10.0.0.1", nil), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if equal := tt.a == tt.b; equal != tt.wantEqual {
+				t.Errorf("scopes %q and %q equal = %t, want %t", tt.a, tt.b, equal, tt.wantEqual)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("POST", "/api/users", nil)
+	if idempotencyScope(r, "a") == idempotencyScope(r, "b") {
+		t.Error("anonymous requests with different bodies share a scope")
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+type IImpersonationHandler interface {
+	Impersonate(w http.ResponseWriter, r *http.Request)
+}
+
+type ImpersonationHandler struct {
+	impersonationService service.IImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService service.IImpersonationService) IImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+func (h *ImpersonationHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	impersonation, err := h.impersonationService.Start(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(impersonation)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+type ILoginActivityHandler interface {
+	GetDevices(w http.ResponseWriter, r *http.Request)
+	GetLoginHistory(w http.ResponseWriter, r *http.Request)
+}
+
+type LoginActivityHandler struct {
+	loginMonitor service.ILoginMonitorService
+}
+
+func NewLoginActivityHandler(loginMonitor service.ILoginMonitorService) ILoginActivityHandler {
+	return &LoginActivityHandler{
+		loginMonitor: loginMonitor,
+	}
+}
+
+func (h *LoginActivityHandler) GetDevices(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	devices, err := h.loginMonitor.GetDevices(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(devices)
+}
+
+func (h *LoginActivityHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	records, err := h.loginMonitor.GetHistory(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
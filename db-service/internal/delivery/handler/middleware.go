@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/requestctx"
+	"awesomeProject22/db-service/internal/service"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const maxRequestIDLength = 128
+
+// unauditedRoutes cannot change entities: GraphQL only serves queries, and
+// logins, including the OIDC callback and the two-factor challenge, record
+// their outcome as security events. Every other auth route is audited.
+var unauditedRoutes = regexp.MustCompile(`^(/graphql|/api(/v[0-9]+)?/auth/(login|login/2fa|oidc/\{provider\}/callback))$`)
+
+type Middleware struct {
+	tokenManager       auth.ITokenManager
+	userService        service.IUserService
+	authzService       service.IAuthzService
+	apiKeyService      service.IAPIKeyService
+	auditService       service.IAuditService
+	usageService       service.IAPIUsageService
+	idempotencyService service.IIdempotencyService
+}
+
+func NewMiddleware(tokenManager auth.ITokenManager, userService service.IUserService, authzService service.IAuthzService,
+	apiKeyService service.IAPIKeyService, auditService service.IAuditService, usageService service.IAPIUsageService,
+	idempotencyService service.IIdempotencyService) *Middleware {
+	return &Middleware{
+		tokenManager:       tokenManager,
+		userService:        userService,
+		authzService:       authzService,
+		apiKeyService:      apiKeyService,
+		auditService:       auditService,
+		usageService:       usageService,
+		idempotencyService: idempotencyService,
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (m *Middleware) ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ctx := requestctx.WithClientInfo(r.Context(), requestctx.ClientInfo{
+			IP:             ip,
+			UserAgent:      r.UserAgent(),
+			AcceptLanguage: r.Header.Get("Accept-Language"),
+			DeviceID:       r.Header.Get("X-Device-ID"),
+			RequestID:      requestID,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate attaches the caller to the request context when a bearer token
+// or an API key is present. Requests without credentials pass through
+// anonymously.
+func (m *Middleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		apiKey := r.Header.Get("X-API-Key")
+		if key, found := strings.CutPrefix(header, "ApiKey "); found {
+			apiKey = key
+		}
+
+		switch {
+		case apiKey != "":
+			key, err := m.apiKeyService.Authenticate(r.Context(), apiKey)
+			if err != nil {
+				if !errors.Is(err, service.ErrInvalidCredentials) {
+					log.Printf("Error authenticating api key: %v", err)
+				}
+				writeUnauthorized(w, r, "invalid or expired API key")
+				return
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), &auth.Principal{
+				APIKeyID:    &key.ID,
+				Permissions: key.Permissions,
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+		case header != "":
+			token, found := strings.CutPrefix(header, "Bearer ")
+			if !found {
+				writeUnauthorized(w, r, "unsupported authorization scheme")
+				return
+			}
+
+			claims, err := m.tokenManager.Parse(token)
+			if err != nil || claims.Purpose != "" {
+				writeUnauthorized(w, r, "invalid or expired token")
+				return
+			}
+
+			principal := &auth.Principal{UserID: claims.Subject}
+			accounts := []uuid.UUID{claims.Subject}
+			if claims.Actor != nil {
+				principal.ImpersonatorID = &claims.Actor.Subject
+				accounts = append(accounts, claims.Actor.Subject)
+			}
+
+			// A token stays valid until it expires, so the accounts behind it
+			// are checked on every request.
+			for _, userID := range accounts {
+				if err := m.userService.EnsureActive(r.Context(), userID); err != nil {
+					if errors.Is(err, service.ErrTokenInvalid) {
+						writeUnauthorized(w, r, "invalid or expired token")
+					} else {
+						writeServiceError(w, r, err)
+					}
+					return
+				}
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Audit records every request that may change state once the handler has
+// finished, including the ones that were rejected.
+func (m *Middleware) Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		switch {
+		case r.Method == http.MethodGet, r.Method == http.MethodHead, r.Method == http.MethodOptions,
+			unauditedRoutes.MatchString(route):
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, scope := service.WithAuditScope(r.Context())
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		m.auditService.RecordRequest(ctx, scope, service.RequestAudit{
+			Method: r.Method,
+			Route:  route,
+			Status: recorder.status,
+		})
+	})
+}
+
+func (m *Middleware) RequireAuthentication(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+			writeUnauthorized(w, r, "authentication required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (m *Middleware) RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+			writeUnauthorized(w, r, "authentication required")
+			return
+		}
+
+		allowed, err := m.authzService.Authorize(r.Context(), permission)
+		if err != nil {
+			writeServiceError(w, r, fmt.Errorf("error checking permission %s: %w", permission, err))
+			return
+		}
+
+		if !allowed {
+			writeForbidden(w, r, "missing permission "+permission)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/service"
+	"context"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUnauditedRoutes(t *testing.T) {
+	tests := []struct {
+		route string
+		want  bool
+	}{
+		{"/graphql", true},
+		{"/api/auth/login", true},
+		{"/api/v1/auth/login/2fa", true},
+		{"/api/v2/auth/oidc/{provider}/callback", true},
+		{"/api/auth/login/2fa/enroll", false},
+		{"/api/auth/login/2fa/activate", false},
+		{"/api/auth/2fa/disable", false},
+		{"/api/v1/auth/2fa/recovery-codes", false},
+		{"/api/auth/password-reset/confirm", false},
+		{"/api/auth/email-verification/confirm", false},
+		{"/api/books", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			if got := unauditedRoutes.MatchString(tt.route); got != tt.want {
+				t.Errorf("unauditedRoutes.MatchString(%q) = %t, want %t", tt.route, got, tt.want)
+			}
+		})
+	}
+}
+
+// activeUsers knows which accounts still exist and are not erased.
+type activeUsers struct {
+	service.IUserService
+	active map[uuid.UUID]bool
+}
+
+func (u *activeUsers) EnsureActive(ctx context.Context, id uuid.UUID) error {
+	if !u.active[id] {
+		return service.ErrTokenInvalid
+	}
+	return nil
+}
+
+func TestAuthenticateRejectsTokensOfErasedAccounts(t *testing.T) {
+	active, erased := uuid.New(), uuid.New()
+	tokenManager := auth.NewTokenManager(auth.TokenConfig{Secret: "secret", TTL: time.Hour})
+	middleware := NewMiddleware(tokenManager, &activeUsers{active: map[uuid.UUID]bool{active: true}},
+		nil, nil, nil, nil, nil)
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	issue := func(token string, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("error issuing token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"active account", issue(tokenManager.Issue(active)), http.StatusNoContent},
+		{"erased account", issue(tokenManager.Issue(erased)), http.StatusUnauthorized},
+		{"impersonated by an erased account", issue(tokenManager.IssueImpersonation(active, erased, time.Hour)),
+			http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/books", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
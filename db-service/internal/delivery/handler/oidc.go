@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+type IOIDCHandler interface {
+	Login(w http.ResponseWriter, r *http.Request)
+	Callback(w http.ResponseWriter, r *http.Request)
+}
+
+type OIDCHandler struct {
+	oidcService service.IOIDCService
+}
+
+func NewOIDCHandler(oidcService service.IOIDCService) IOIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.oidcService.BeginLogin(r.Context(), mux.Vars(r)["provider"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		writeUnauthorized(w, r, "identity provider returned error: "+providerError)
+		return
+	}
+
+	result, err := h.oidcService.CompleteLogin(r.Context(), mux.Vars(r)["provider"], query.Get("state"), query.Get("code"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,432 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/delivery/dto"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/jsonpatch"
+	"awesomeProject22/db-service/internal/openapi"
+	"awesomeProject22/db-service/internal/service"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// access levels of an apiRoute besides a permission name.
+const (
+	accessPublic        = ""
+	accessAuthenticated = "authenticated"
+)
+
+// apiRoute documents one route of Router.RegisterRoutes. Path parameters are
+// derived from the path; the error responses from what the route accepts.
+// Paths are relative to the API version unless the route is unversioned.
+type apiRoute struct {
+	method      string
+	path        string
+	unversioned bool
+	tag         string
+	summary     string
+	access      string
+	query       []openapi.Parameter
+	request     interface{}
+	patch       interface{}
+	ifMatch     bool
+	idempotent  bool
+	conditional bool
+	etag        bool
+	status      int
+	response    interface{}
+	v2Response  interface{}
+	paged       bool
+	contentType string
+	errors      []int
+}
+
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func queryParameter(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+var (
+	stringSchema   = &openapi.Schema{Type: "string"}
+	uuidSchema     = &openapi.Schema{Type: "string", Format: "uuid"}
+	dateTimeSchema = &openapi.Schema{Type: "string", Format: "date-time"}
+	integerSchema  = &openapi.Schema{Type: "integer"}
+)
+
+var apiRoutes = []apiRoute{
+	{method: http.MethodGet, path: "/books", tag: "Books", summary: "List books", conditional: true,
+		query: []openapi.Parameter{
+			queryParameter("author", "Only books by this author.", stringSchema),
+			queryParameter("genre", "Only books of this genre.", stringSchema),
+		},
+		status: http.StatusOK, response: []dto.BookResponse{}, v2Response: dto.BookPage{}, paged: true},
+	{method: http.MethodGet, path: "/books/{id}", tag: "Books", summary: "Get a book", conditional: true,
+		status: http.StatusOK, response: dto.BookResponse{}, v2Response: dto.BookResponseV2{}},
+	{method: http.MethodPost, path: "/books", tag: "Books", summary: "Create a book", access: domain.PermissionBooksWrite,
+		request: dto.BookRequest{}, idempotent: true, etag: true, status: http.StatusCreated, response: dto.BookResponse{}, v2Response: dto.BookResponseV2{},
+		errors: []int{http.StatusConflict}},
+	{method: http.MethodPut, path: "/books/{id}", tag: "Books", summary: "Replace a book", access: domain.PermissionBooksWrite,
+		request: dto.BookRequest{}, ifMatch: true, status: http.StatusOK, response: dto.BookResponse{}, v2Response: dto.BookResponseV2{},
+		errors: []int{http.StatusConflict}},
+	{method: http.MethodPatch, path: "/books/{id}", tag: "Books", summary: "Patch a book", access: domain.PermissionBooksWrite,
+		patch: dto.BookRequest{}, ifMatch: true, status: http.StatusOK, response: dto.BookResponse{}, v2Response: dto.BookResponseV2{},
+		errors: []int{http.StatusConflict}},
+	{method: http.MethodDelete, path: "/books/{id}", tag: "Books", summary: "Delete a book", access: domain.PermissionBooksWrite,
+		ifMatch: true, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/books:batch", tag: "Books", summary: "Create, update and delete books in one transaction",
+		access: domain.PermissionBooksWrite, request: dto.BookBatchRequest{}, status: http.StatusOK, response: dto.BatchResponse{},
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed}},
+
+	{method: http.MethodGet, path: "/users", tag: "Users", summary: "List users", access: domain.PermissionUsersRead,
+		status: http.StatusOK, response: []dto.UserResponse{}, v2Response: dto.UserPage{}, paged: true},
+	{method: http.MethodGet, path: "/users/{id}", tag: "Users", summary: "Get a user", access: accessAuthenticated,
+		etag: true, status: http.StatusOK, response: dto.UserResponse{}, v2Response: dto.UserResponseV2{}},
+	{method: http.MethodPost, path: "/users", tag: "Users", summary: "Register a user",
+		request: dto.CreateUserRequest{}, idempotent: true, etag: true, status: http.StatusCreated, response: dto.UserResponse{}, v2Response: dto.UserResponseV2{},
+		errors: []int{http.StatusConflict}},
+	{method: http.MethodPut, path: "/users/{id}", tag: "Users", summary: "Update a user", access: accessAuthenticated,
+		request: dto.UpdateUserRequest{}, ifMatch: true, status: http.StatusOK, response: dto.UserResponse{}, v2Response: dto.UserResponseV2{},
+		errors: []int{http.StatusConflict}},
+	{method: http.MethodPatch, path: "/users/{id}", tag: "Users", summary: "Patch a user", access: accessAuthenticated,
+		patch: dto.UpdateUserRequest{}, ifMatch: true, status: http.StatusOK, response: dto.UserResponse{}, v2Response: dto.UserResponseV2{},
+		errors: []int{http.StatusConflict}},
+	{method: http.MethodDelete, path: "/users/{id}", tag: "Users", summary: "Delete a user", access: accessAuthenticated,
+		ifMatch: true, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/users:batch", tag: "Users", summary: "Create, update and delete users in one transaction",
+		access: domain.PermissionUsersWrite, request: dto.UserBatchRequest{}, status: http.StatusOK, response: dto.BatchResponse{},
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed}},
+	{method: http.MethodGet, path: "/users/{id}/data-export", tag: "Privacy", summary: "Export all personal data as a zip archive",
+		access: accessAuthenticated, status: http.StatusOK, contentType: "application/zip"},
+	{method: http.MethodPost, path: "/users/{id}/erasure", tag: "Privacy", summary: "Erase a user's personal data",
+		access: accessAuthenticated, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/users/{id}/devices", tag: "Users", summary: "List the devices a user signed in from",
+		access: accessAuthenticated, status: http.StatusOK, response: []domain.KnownDevice{}},
+	{method: http.MethodGet, path: "/users/{id}/logins", tag: "Users", summary: "List recent logins of a user",
+		access: accessAuthenticated, status: http.StatusOK, response: []domain.LoginRecord{}},
+
+	{method: http.MethodPost, path: "/auth/login", tag: "Authentication", summary: "Log in with username and password",
+		request: dto.LoginRequest{}, status: http.StatusOK, response: service.AuthResult{},
+		errors: []int{http.StatusUnauthorized, http.StatusTooManyRequests}},
+	{method: http.MethodGet, path: "/auth/oidc/{provider}/login", tag: "Authentication", summary: "Start a login with an identity provider",
+		status: http.StatusFound},
+	{method: http.MethodGet, path: "/auth/oidc/{provider}/callback", tag: "Authentication", summary: "Finish a login with an identity provider",
+		query: []openapi.Parameter{
+			queryParameter("state", "State issued by the login redirect.", stringSchema),
+			queryParameter("code", "Authorization code from the provider.", stringSchema),
+			queryParameter("error", "Error reported by the provider.", stringSchema),
+		},
+		status: http.StatusOK, response: service.AuthResult{}, errors: []int{http.StatusUnauthorized, http.StatusForbidden}},
+	{method: http.MethodPost, path: "/auth/login/2fa", tag: "Two-factor authentication", summary: "Finish a login with a second factor",
+		request: twoFactorInput{}, status: http.StatusOK, response: tokenResponse{}, errors: []int{http.StatusUnauthorized}},
+	{method: http.MethodPost, path: "/auth/login/2fa/enroll", tag: "Two-factor authentication", summary: "Enroll a second factor during login",
+		request: twoFactorInput{}, status: http.StatusOK, response: service.Enrollment{}, errors: []int{http.StatusUnauthorized}},
+	{method: http.MethodPost, path: "/auth/login/2fa/activate", tag: "Two-factor authentication", summary: "Activate the second factor enrolled during login",
+		request: twoFactorInput{}, status: http.StatusOK, response: enrollmentCompletedResponse{}, errors: []int{http.StatusUnauthorized}},
+	{method: http.MethodPost, path: "/auth/2fa/enroll", tag: "Two-factor authentication", summary: "Enroll a second factor",
+		access: accessAuthenticated, status: http.StatusOK, response: service.Enrollment{}},
+	{method: http.MethodPost, path: "/auth/2fa/activate", tag: "Two-factor authentication", summary: "Activate the enrolled second factor",
+		access: accessAuthenticated, request: twoFactorInput{}, status: http.StatusOK, response: recoveryCodesResponse{}},
+	{method: http.MethodPost, path: "/auth/2fa/disable", tag: "Two-factor authentication", summary: "Disable the second factor",
+		access: accessAuthenticated, request: twoFactorInput{}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/auth/2fa/recovery-codes", tag: "Two-factor authentication", summary: "Replace the recovery codes",
+		access: accessAuthenticated, request: twoFactorInput{}, status: http.StatusOK, response: recoveryCodesResponse{}},
+	{method: http.MethodPost, path: "/auth/password-reset/request", tag: "Account", summary: "Send a password reset link",
+		request: emailInput{}, status: http.StatusAccepted},
+	{method: http.MethodPost, path: "/auth/password-reset/confirm", tag: "Account", summary: "Set a new password with a reset token",
+		request: passwordResetInput{}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/auth/email-verification/request", tag: "Account", summary: "Send an email verification link",
+		request: emailInput{}, status: http.StatusAccepted},
+	{method: http.MethodPost, path: "/auth/email-verification/confirm", tag: "Account", summary: "Verify an email address",
+		request: tokenInput{}, status: http.StatusNoContent},
+
+	{method: http.MethodPost, path: "/admin/users/{id}/unlock", tag: "Administration", summary: "Lift a login lockout",
+		access: domain.PermissionUsersWrite, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/admin/users/{id}/impersonate", tag: "Administration", summary: "Issue a token to act as a user",
+		access: domain.PermissionImpersonate, status: http.StatusOK, response: service.Impersonation{}},
+	{method: http.MethodGet, path: "/admin/roles", tag: "Administration", summary: "List roles",
+		access: domain.PermissionRolesManage, status: http.StatusOK, response: []domain.Role{}},
+	{method: http.MethodGet, path: "/admin/users/{id}/roles", tag: "Administration", summary: "List the roles of a user",
+		access: domain.PermissionRolesManage, status: http.StatusOK, response: []domain.Role{}},
+	{method: http.MethodPost, path: "/admin/users/{id}/roles", tag: "Administration", summary: "Grant a role",
+		access: domain.PermissionRolesManage, request: grantRoleInput{}, status: http.StatusNoContent},
+	{method: http.MethodDelete, path: "/admin/users/{id}/roles/{role}", tag: "Administration", summary: "Revoke a role",
+		access: domain.PermissionRolesManage, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/admin/audit", tag: "Audit", summary: "Search the audit log",
+		access: domain.PermissionAuditRead,
+		query: []openapi.Parameter{
+			queryParameter("action", "Only entries with this action.", stringSchema),
+			queryParameter("entity_type", "Only entries about this kind of entity.", stringSchema),
+			queryParameter("entity_id", "Only entries about this entity.", stringSchema),
+			queryParameter("request_id", "Only entries of this request.", stringSchema),
+			queryParameter("actor_id", "Only entries by this user.", uuidSchema),
+			queryParameter("on_behalf_of", "Only entries made while impersonating this user.", uuidSchema),
+			queryParameter("from", "Earliest time, RFC 3339.", dateTimeSchema),
+			queryParameter("to", "Latest time, RFC 3339.", dateTimeSchema),
+			queryParameter("limit", "Maximum number of entries.", integerSchema),
+			queryParameter("offset", "Number of entries to skip.", integerSchema),
+		},
+		status: http.StatusOK, response: []domain.AuditEntry{}},
+	{method: http.MethodGet, path: "/admin/audit/verify", tag: "Audit", summary: "Verify the audit hash chain",
+		access: domain.PermissionAuditRead, status: http.StatusOK, response: domain.AuditVerification{}},
+	{method: http.MethodGet, path: "/admin/api-usage", tag: "Administration", summary: "Count requests per API version",
+		access: domain.PermissionAuditRead,
+		query: []openapi.Parameter{
+			queryParameter("days", fmt.Sprintf("Number of days to count, at most %d.", service.MaxAPIUsageDays), integerSchema),
+		},
+		status: http.StatusOK, response: []apiUsageResponse{}},
+	{method: http.MethodGet, path: "/admin/keys", tag: "API keys", summary: "List API keys",
+		access: domain.PermissionKeysManage, status: http.StatusOK, response: []domain.APIKey{}},
+	{method: http.MethodPost, path: "/admin/keys", tag: "API keys", summary: "Create an API key",
+		access: domain.PermissionKeysManage, request: apiKeyInput{}, status: http.StatusCreated, response: apiKeyResponse{}},
+	{method: http.MethodPost, path: "/admin/keys/{id}/rotate", tag: "API keys", summary: "Rotate an API key",
+		access: domain.PermissionKeysManage, status: http.StatusOK, response: apiKeyResponse{}},
+	{method: http.MethodDelete, path: "/admin/keys/{id}", tag: "API keys", summary: "Revoke an API key",
+		access: domain.PermissionKeysManage, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/api/openapi.json", tag: "Documentation", summary: "This OpenAPI document",
+		unversioned: true, status: http.StatusOK, contentType: "application/json"},
+	{method: http.MethodGet, path: "/api/docs", tag: "Documentation", summary: "Interactive API documentation",
+		unversioned: true, status: http.StatusOK, contentType: "text/html"},
+
+	{method: http.MethodGet, path: "/graphql", tag: "GraphQL", summary: "Run a GraphQL query given as query parameters",
+		unversioned: true,
+		query: []openapi.Parameter{
+			queryParameter("query", "The GraphQL document.", stringSchema),
+			queryParameter("operationName", "Operation to run when the document holds several.", stringSchema),
+			queryParameter("variables", "Variables of the operation as a JSON object.", stringSchema),
+		},
+		status: http.StatusOK, response: dto.GraphQLResponse{}, errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity}},
+	{method: http.MethodPost, path: "/graphql", tag: "GraphQL", summary: "Run a GraphQL query",
+		unversioned: true, request: dto.GraphQLRequest{}, status: http.StatusOK, response: dto.GraphQLResponse{}},
+}
+
+// sharedResponses names the component response of every error status.
+var sharedResponses = map[int]string{
+	http.StatusNotModified:           "NotModified",
+	http.StatusBadRequest:            "BadRequest",
+	http.StatusUnauthorized:          "Unauthorized",
+	http.StatusForbidden:             "Forbidden",
+	http.StatusNotFound:              "NotFound",
+	http.StatusConflict:              "Conflict",
+	http.StatusPreconditionFailed:    "PreconditionFailed",
+	http.StatusRequestEntityTooLarge: "PayloadTooLarge",
+	http.StatusUnsupportedMediaType:  "UnsupportedMediaType",
+	http.StatusUnprocessableEntity:   "ValidationFailed",
+	http.StatusPreconditionRequired:  "PreconditionRequired",
+	http.StatusTooManyRequests:       "TooManyRequests",
+	http.StatusInternalServerError:   "InternalError",
+}
+
+var parameterOrder = map[string]int{"path": 0, "query": 1, "header": 2}
+
+var pathParameterPattern = regexp.MustCompile(`{([^}:]+)}`)
+
+// apiMounts lists where the versioned routes are served; bare /api serves v1.
+var apiMounts = []struct {
+	prefix  string
+	version string
+}{
+	{"/api", APIVersion1},
+	{"/api/" + APIVersion1, APIVersion1},
+	{"/api/" + APIVersion2, APIVersion2},
+}
+
+// newAPIDocument describes apiRoutes under every API version as an OpenAPI
+// document.
+func newAPIDocument(versions map[string]VersionPolicy) *openapi.Document {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Library API",
+		Description: "Books, users and their administration. Errors share one JSON envelope.",
+		Version:     "1.0.0",
+	})
+
+	doc.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+		Description: "Access token from a login.",
+	}
+	doc.Components.SecuritySchemes["apiKey"] = &openapi.SecurityScheme{
+		Type: "apiKey", In: "header", Name: "X-API-Key",
+		Description: "API key, also accepted as `Authorization: ApiKey <key>`.",
+	}
+
+	errorSchema := doc.SchemaOf(errorResponse{})
+	for status, name := range sharedResponses {
+		response := &openapi.Response{Description: http.StatusText(status)}
+		if status != http.StatusNotModified {
+			response.Content = openapi.JSONContent(errorSchema)
+		}
+		if status == http.StatusTooManyRequests {
+			response.Headers = map[string]*openapi.Header{
+				"Retry-After": {Description: "Seconds to wait before retrying.", Schema: integerSchema},
+			}
+		}
+		doc.Components.Responses[name] = response
+	}
+
+	tags := map[string]bool{}
+	for _, route := range apiRoutes {
+		if !tags[route.tag] {
+			tags[route.tag] = true
+			doc.Tags = append(doc.Tags, openapi.Tag{Name: route.tag})
+		}
+		if route.unversioned {
+			doc.AddOperation(route.method, route.path, route.operation(doc, route.path, APIVersion1, VersionPolicy{}))
+			continue
+		}
+		for _, mount := range apiMounts {
+			path := mount.prefix + route.path
+			doc.AddOperation(route.method, path, route.operation(doc, path, mount.version, versions[mount.version]))
+		}
+	}
+
+	return doc
+}
+
+func (route apiRoute) operation(doc *openapi.Document, path, version string, policy VersionPolicy) *openapi.Operation {
+	operation := &openapi.Operation{
+		Tags:        []string{route.tag},
+		Summary:     route.summary,
+		OperationID: operationID(route.method, path),
+		Parameters:  append([]openapi.Parameter{}, route.query...),
+		Responses:   map[string]*openapi.Response{},
+		Deprecated:  policy.Deprecated(),
+	}
+	errors := append([]int{http.StatusInternalServerError}, route.errors...)
+
+	response := route.response
+	if version == APIVersion2 && route.v2Response != nil {
+		response = route.v2Response
+	}
+	if version == APIVersion2 && route.paged {
+		operation.Parameters = append(operation.Parameters,
+			queryParameter("limit", fmt.Sprintf("Page size, at most %d. Defaults to %d.", dto.MaxPageLimit, dto.DefaultPageLimit), integerSchema),
+			queryParameter("offset", "Number of items to skip.", integerSchema),
+		)
+		errors = append(errors, http.StatusBadRequest)
+	}
+
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(route.path, -1) {
+		schema := stringSchema
+		if match[1] == "id" {
+			schema = uuidSchema
+			errors = append(errors, http.StatusBadRequest, http.StatusNotFound)
+		}
+		operation.Parameters = append(operation.Parameters, openapi.Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+
+	switch route.access {
+	case accessPublic:
+	case accessAuthenticated:
+		operation.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}, {"apiKey": {}}}
+		errors = append(errors, http.StatusUnauthorized, http.StatusForbidden)
+	default:
+		operation.Description = fmt.Sprintf("Requires the `%s` permission.", route.access)
+		operation.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}, {"apiKey": {}}}
+		errors = append(errors, http.StatusUnauthorized, http.StatusForbidden)
+	}
+
+	if route.request != nil {
+		operation.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSONContent(doc.SchemaOf(route.request))}
+		errors = append(errors, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity)
+	}
+	if route.patch != nil {
+		fields := doc.SchemaOf(route.patch)
+		mergePatch := &openapi.Schema{Type: "object", Description: "Members of " + strings.TrimPrefix(fields.Ref, "#/components/schemas/") +
+			" to change, as an RFC 7396 merge patch. Plain application/json is read as a merge patch too."}
+		operation.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			jsonpatch.MediaTypeMergePatch: {Schema: mergePatch},
+			"application/json":            {Schema: mergePatch},
+			jsonpatch.MediaTypeJSONPatch:  {Schema: doc.SchemaOf([]jsonPatchOperation{})},
+		}}
+		errors = append(errors, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType,
+			http.StatusUnprocessableEntity)
+	}
+
+	etag := map[string]*openapi.Header{"ETag": {Description: "Current version of the resource.", Schema: stringSchema}}
+	if route.ifMatch {
+		operation.Parameters = append(operation.Parameters, openapi.Parameter{
+			Name: "If-Match", In: "header", Required: true, Schema: stringSchema,
+			Description: "ETag of the version being changed, or * to skip the check.",
+		})
+		errors = append(errors, http.StatusPreconditionFailed, http.StatusPreconditionRequired)
+	}
+	if route.idempotent {
+		maxLength := int64(maxIdempotencyKeyLength)
+		operation.Parameters = append(operation.Parameters, openapi.Parameter{
+			Name: idempotencyKeyHeader, In: "header", Schema: &openapi.Schema{Type: "string", MaxLength: &maxLength},
+			Description: "Unique key of this request. Retries with the same key and body replay the first response.",
+		})
+		errors = append(errors, http.StatusConflict, http.StatusUnprocessableEntity)
+	}
+	if route.conditional {
+		operation.Parameters = append(operation.Parameters,
+			openapi.Parameter{Name: "If-None-Match", In: "header", Schema: stringSchema,
+				Description: "ETags the client already has."},
+			openapi.Parameter{Name: "If-Modified-Since", In: "header", Schema: stringSchema,
+				Description: "Time of the copy the client already has, ignored when If-None-Match is sent."},
+		)
+		etag["Last-Modified"] = &openapi.Header{Description: "Time of the latest change.", Schema: stringSchema}
+		etag["Cache-Control"] = &openapi.Header{Description: "Caching policy of the catalog.", Schema: stringSchema}
+		errors = append(errors, http.StatusNotModified)
+	}
+
+	success := &openapi.Response{Description: http.StatusText(route.status), Headers: map[string]*openapi.Header{}}
+	switch {
+	case response != nil:
+		success.Content = openapi.JSONContent(doc.SchemaOf(response))
+	case route.contentType != "":
+		success.Content = map[string]openapi.MediaType{route.contentType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}
+	}
+	if route.status == http.StatusFound {
+		success.Headers["Location"] = &openapi.Header{Description: "Authorization URL of the provider.", Schema: stringSchema}
+		errors = append(errors, http.StatusNotFound)
+	}
+	if route.etag || route.conditional || route.ifMatch && route.status != http.StatusNoContent {
+		success.Headers = etag
+	}
+	if route.idempotent {
+		success.Headers[idempotentReplayedHeader] = &openapi.Header{
+			Description: "Set to true when the response is a replay of an earlier request.", Schema: stringSchema}
+	}
+	if policy.Deprecated() {
+		success.Headers["Deprecation"] = &openapi.Header{Description: "When this API version was deprecated.", Schema: stringSchema}
+		if !policy.Sunset.IsZero() {
+			success.Headers["Sunset"] = &openapi.Header{Description: "When this API version stops being served.", Schema: stringSchema}
+		}
+		if successorVersion(version) != "" {
+			success.Headers["Link"] = &openapi.Header{Description: "The successor version of this API.", Schema: stringSchema}
+		}
+	}
+	operation.Responses[strconv.Itoa(route.status)] = success
+
+	for _, status := range errors {
+		operation.Responses[strconv.Itoa(status)] = openapi.RefResponse(sharedResponses[status])
+	}
+
+	sort.SliceStable(operation.Parameters, func(i, j int) bool {
+		return parameterOrder[operation.Parameters[i].In] < parameterOrder[operation.Parameters[j].In]
+	})
+	return operation
+}
+
+// operationID turns "GET /api/v2/users/{id}/roles" into "getV2UsersIdRoles".
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.FieldsFunc(strings.TrimPrefix(path, "/api"), func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.' || r == ':'
+	}) {
+		id.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return id.String()
+}
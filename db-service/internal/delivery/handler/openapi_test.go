@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func registeredRoutes(t *testing.T) map[string]bool {
+	t.Helper()
+
+	router := NewRouter(NewBookHandler(nil, CacheConfig{}), NewUserHandler(nil), NewRoleHandler(nil), NewAccountHandler(nil),
+		NewTwoFactorHandler(nil, nil), NewAPIKeyHandler(nil), NewOIDCHandler(nil), NewPrivacyHandler(nil), NewAuditHandler(nil),
+		NewImpersonationHandler(nil), NewLoginActivityHandler(nil), NewAPIUsageHandler(nil, nil), NewDocsHandler(nil),
+		NewGraphQLHandler(nil, nil, nil, GraphQLConfig{}), nil,
+		NewMiddleware(nil, nil, nil, nil, nil, nil, nil))
+
+	muxRouter := mux.NewRouter()
+	router.RegisterRoutes(muxRouter)
+
+	routes := map[string]bool{}
+	err := muxRouter.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			// Version prefixes only mount subrouters.
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			t.Errorf("route %s does not restrict its methods", path)
+			return nil
+		}
+		for _, method := range methods {
+			routes[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking routes: %v", err)
+	}
+	return routes
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	doc := newAPIDocument(nil)
+	routes := registeredRoutes(t)
+
+	for route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := doc.Operation(method, path); !ok {
+			t.Errorf("route %s is missing from the OpenAPI document", route)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !routes[strings.ToUpper(method)+" "+path] {
+				t.Errorf("OpenAPI document describes %s %s, which is not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	spec, err := json.Marshal(newAPIDocument(nil))
+	if err != nil {
+		t.Fatalf("error rendering document: %v", err)
+	}
+
+	var document struct {
+		Components struct {
+			Schemas   map[string]json.RawMessage `json:"schemas"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(spec, &document); err != nil {
+		t.Fatalf("error decoding document: %v", err)
+	}
+
+	references := regexp.MustCompile(`"\$ref":"#/components/(schemas|responses)/([^"]+)"`)
+	for _, match := range references.FindAllStringSubmatch(string(spec), -1) {
+		components := document.Components.Schemas
+		if match[1] == "responses" {
+			components = document.Components.Responses
+		}
+		if _, ok := components[match[2]]; !ok {
+			t.Errorf("reference %s/%s does not resolve", match[1], match[2])
+		}
+	}
+}
+
+func TestOpenAPIIsServed(t *testing.T) {
+	handler := NewDocsHandler(nil)
+
+	recorder := httptest.NewRecorder()
+	handler.OpenAPISpec(recorder, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("served document is not JSON: %v", err)
+	}
+	if document["openapi"] != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", document["openapi"])
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.DocsUI(recorder, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if !strings.Contains(recorder.Body.String(), "/api/openapi.json") {
+		t.Error("docs page does not load the OpenAPI document")
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"archive/zip"
+	"awesomeProject22/db-service/internal/delivery/dto"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+)
+
+const exportReadme = `Personal data export
+
+profile.json          account data
+roles.json            roles assigned to the account
+loans.json            books currently borrowed
+security_events.json  security history (logins locked, password resets, 2FA changes, role changes, ...)
+identities.json       accounts linked through external identity providers
+login_history.json    past logins with IP address, browser and device fingerprint
+devices.json          devices the account has signed in from
+two_factor.json       two-factor authentication status (secrets are never exported)
+
+The library does not record holds or fines, so no such files are included.
+
+Erasing the account removes or anonymizes all of the above except the loans
+and the security events, which stay linked to the account ID so changes and
+security incidents can still be accounted for. Their IP addresses, networks,
+browsers and device fingerprints are removed. The audit log of changes keeps
+the account ID and the IP address of each request but never the values of
+personal fields; it is append-only, so it cannot be altered afterwards.
+`
+
+type IPrivacyHandler interface {
+	ExportData(w http.ResponseWriter, r *http.Request)
+	EraseUser(w http.ResponseWriter, r *http.Request)
+}
+
+type PrivacyHandler struct {
+	privacyService service.IPrivacyService
+}
+
+func NewPrivacyHandler(privacyService service.IPrivacyService) IPrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+func (h *PrivacyHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	export, err := h.privacyService.Export(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", dto.ToUserResponse(&export.User)},
+		{"roles.json", export.Roles},
+		{"loans.json", dto.ToBookResponses(toBookPointers(export.Loans))},
+		{"security_events.json", export.SecurityEvents},
+		{"identities.json", export.Identities},
+		{"login_history.json", export.LoginHistory},
+		{"devices.json", export.Devices},
+		{"two_factor.json", export.TwoFactor},
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s-%s.zip"`, id, export.GeneratedAt.Format("20060102T150405Z")))
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			log.Printf("Error writing %s to data export: %v", file.name, err)
+			return
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			log.Printf("Error writing %s to data export: %v", file.name, err)
+			return
+		}
+	}
+
+	readme, err := archive.Create("README.txt")
+	if err == nil {
+		_, err = readme.Write([]byte(exportReadme))
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		log.Printf("Error finishing data export of user %s: %v", id, err)
+	}
+}
+
+func (h *PrivacyHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	if err := h.privacyService.Erase(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toBookPointers(books []domain.Book) []*domain.Book {
+	pointers := make([]*domain.Book, len(books))
+	for i := range books {
+		pointers[i] = &books[i]
+	}
+	return pointers
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/jsonpatch"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const maxRequestBodyBytes = 1 << 20
+
+var (
+	errBodyTooLarge         = fmt.Errorf("request body exceeds %d bytes", maxRequestBodyBytes)
+	errUnsupportedMediaType = fmt.Errorf("unsupported media type, use %s or %s", jsonpatch.MediaTypeMergePatch, jsonpatch.MediaTypeJSONPatch)
+)
+
+// decodeJSON reads exactly one JSON object into dst, rejecting unknown fields
+// and oversized bodies, and then applies the `validate` tags of dst.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return decodeError(err)
+		}
+		return fmt.Errorf("%w: request body must contain a single JSON object", service.ErrInvalidInput)
+	}
+
+	return service.Validate(dst)
+}
+
+// readPatch returns the patch body and its media type. Plain application/json
+// is treated as a merge patch.
+func readPatch(w http.ResponseWriter, r *http.Request) (string, []byte, error) {
+	mediaType := jsonpatch.MediaTypeMergePatch
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return "", nil, errUnsupportedMediaType
+		}
+		switch parsed {
+		case "application/json", jsonpatch.MediaTypeMergePatch:
+		case jsonpatch.MediaTypeJSONPatch:
+			mediaType = parsed
+		default:
+			return "", nil, errUnsupportedMediaType
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	if err != nil {
+		return "", nil, decodeError(err)
+	}
+	if len(body) == 0 {
+		return "", nil, fmt.Errorf("%w: request body is empty", service.ErrInvalidInput)
+	}
+	return mediaType, body, nil
+}
+
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return errBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: request body is empty", service.ErrInvalidInput)
+	default:
+		return fmt.Errorf("%w: malformed request body: %v", service.ErrInvalidInput, err)
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+type IRoleHandler interface {
+	ListRoles(w http.ResponseWriter, r *http.Request)
+	GetUserRoles(w http.ResponseWriter, r *http.Request)
+	GrantRole(w http.ResponseWriter, r *http.Request)
+	RevokeRole(w http.ResponseWriter, r *http.Request)
+}
+
+type RoleHandler struct {
+	authzService service.IAuthzService
+}
+
+func NewRoleHandler(authzService service.IAuthzService) IRoleHandler {
+	return &RoleHandler{
+		authzService: authzService,
+	}
+}
+
+type grantRoleInput struct {
+	Role string `json:"role" validate:"required,max=64"`
+}
+
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.authzService.ListRoles(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(roles)
+}
+
+func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	roles, err := h.authzService.GetUserRoles(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(roles)
+}
+
+func (h *RoleHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	var roleInput grantRoleInput
+
+	if err := decodeJSON(w, r, &roleInput); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.authzService.GrantRole(r.Context(), id, roleInput.Role); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RoleHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	if err := h.authzService.RevokeRole(r.Context(), id, vars["role"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
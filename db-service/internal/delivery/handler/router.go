@@ -1,33 +1,128 @@
 package handler
 
 import (
+	"awesomeProject22/db-service/internal/domain"
 	"github.com/gorilla/mux"
 )
 
 type Router struct {
-	bookHandler IBookHandler
-	userHandler IUserHandler
+	bookHandler          IBookHandler
+	userHandler          IUserHandler
+	roleHandler          IRoleHandler
+	accountHandler       IAccountHandler
+	twoFactorHandler     ITwoFactorHandler
+	apiKeyHandler        IAPIKeyHandler
+	oidcHandler          IOIDCHandler
+	privacyHandler       IPrivacyHandler
+	auditHandler         IAuditHandler
+	impersonationHandler IImpersonationHandler
+	loginActivityHandler ILoginActivityHandler
+	apiUsageHandler      IAPIUsageHandler
+	docsHandler          IDocsHandler
+	graphqlHandler       IGraphQLHandler
+	versions             map[string]VersionPolicy
+	middleware           *Middleware
 }
 
-func NewRouter(bookHandler IBookHandler, userHandler IUserHandler) *Router {
+func NewRouter(bookHandler IBookHandler, userHandler IUserHandler, roleHandler IRoleHandler,
+	accountHandler IAccountHandler, twoFactorHandler ITwoFactorHandler, apiKeyHandler IAPIKeyHandler,
+	oidcHandler IOIDCHandler, privacyHandler IPrivacyHandler, auditHandler IAuditHandler, impersonationHandler IImpersonationHandler,
+	loginActivityHandler ILoginActivityHandler, apiUsageHandler IAPIUsageHandler, docsHandler IDocsHandler,
+	graphqlHandler IGraphQLHandler, versions map[string]VersionPolicy, middleware *Middleware) *Router {
 	return &Router{
-		bookHandler: bookHandler,
-		userHandler: userHandler,
+		bookHandler:          bookHandler,
+		userHandler:          userHandler,
+		roleHandler:          roleHandler,
+		accountHandler:       accountHandler,
+		twoFactorHandler:     twoFactorHandler,
+		apiKeyHandler:        apiKeyHandler,
+		oidcHandler:          oidcHandler,
+		privacyHandler:       privacyHandler,
+		auditHandler:         auditHandler,
+		impersonationHandler: impersonationHandler,
+		loginActivityHandler: loginActivityHandler,
+		apiUsageHandler:      apiUsageHandler,
+		docsHandler:          docsHandler,
+		graphqlHandler:       graphqlHandler,
+		versions:             versions,
+		middleware:           middleware,
 	}
 }
 
 func (r *Router) RegisterRoutes(router *mux.Router) {
+	router.Use(r.middleware.ClientInfo, r.middleware.Authenticate, r.middleware.Audit)
 
-	router.HandleFunc("/api/books", r.bookHandler.GetAllBooks).Methods("GET")
-	router.HandleFunc("/api/books/{id}", r.bookHandler.GetBook).Methods("GET")
-	router.HandleFunc("/api/books", r.bookHandler.CreateBook).Methods("POST")
-	router.HandleFunc("/api/books/{id}", r.bookHandler.UpdateBook).Methods("PUT")
-	router.HandleFunc("/api/books/{id}", r.bookHandler.DeleteBook).Methods("DELETE")
-
-	router.HandleFunc("/api/users", r.userHandler.GetAllUsers).Methods("GET")
-	router.HandleFunc("/api/users/{id}", r.userHandler.GetUser).Methods("GET")
-	router.HandleFunc("/api/users", r.userHandler.CreateUser).Methods("POST")
-	router.HandleFunc("/api/users/{id}", r.userHandler.UpdateUser).Methods("PUT")
-	router.HandleFunc("/api/users/{id}", r.userHandler.DeleteUser).Methods("DELETE")
-	router.HandleFunc("/api/auth/login", r.userHandler.Login).Methods("POST")
+	router.HandleFunc("/api/openapi.json", r.docsHandler.OpenAPISpec).Methods("GET")
+	router.HandleFunc("/api/docs", r.docsHandler.DocsUI).Methods("GET")
+	router.HandleFunc("/graphql", r.graphqlHandler.Query).Methods("GET", "POST")
+
+	for _, version := range apiVersions {
+		api := router.PathPrefix("/api/" + version).Subrouter()
+		api.Use(r.middleware.APIVersion(version, r.versions[version]))
+		r.registerAPI(api)
+	}
+
+	// Unversioned paths predate versioning and keep serving v1.
+	legacy := router.PathPrefix("/api").Subrouter()
+	legacy.Use(r.middleware.APIVersion(APIVersion1, r.versions[APIVersion1]))
+	r.registerAPI(legacy)
+}
+
+// registerAPI adds the routes every API version shares. Handlers shape their
+// responses after the version in the request context.
+func (r *Router) registerAPI(api *mux.Router) {
+	requires := r.middleware.RequirePermission
+	authenticated := r.middleware.RequireAuthentication
+	idempotent := r.middleware.Idempotent
+
+	api.HandleFunc("/books", r.bookHandler.GetAllBooks).Methods("GET")
+	api.HandleFunc("/books/{id}", r.bookHandler.GetBook).Methods("GET")
+	api.HandleFunc("/books", requires(domain.PermissionBooksWrite, idempotent(r.bookHandler.CreateBook))).Methods("POST")
+	api.HandleFunc("/books/{id}", requires(domain.PermissionBooksWrite, r.bookHandler.UpdateBook)).Methods("PUT")
+	api.HandleFunc("/books/{id}", requires(domain.PermissionBooksWrite, r.bookHandler.PatchBook)).Methods("PATCH")
+	api.HandleFunc("/books/{id}", requires(domain.PermissionBooksWrite, r.bookHandler.DeleteBook)).Methods("DELETE")
+	api.HandleFunc("/books:batch", requires(domain.PermissionBooksWrite, r.bookHandler.BatchBooks)).Methods("POST")
+
+	api.HandleFunc("/users", requires(domain.PermissionUsersRead, r.userHandler.GetAllUsers)).Methods("GET")
+	api.HandleFunc("/users/{id}", authenticated(r.userHandler.GetUser)).Methods("GET")
+	api.HandleFunc("/users", idempotent(r.userHandler.CreateUser)).Methods("POST")
+	api.HandleFunc("/users/{id}", authenticated(r.userHandler.UpdateUser)).Methods("PUT")
+	api.HandleFunc("/users/{id}", authenticated(r.userHandler.PatchUser)).Methods("PATCH")
+	api.HandleFunc("/users/{id}", authenticated(r.userHandler.DeleteUser)).Methods("DELETE")
+	api.HandleFunc("/users:batch", requires(domain.PermissionUsersWrite, r.userHandler.BatchUsers)).Methods("POST")
+	api.HandleFunc("/users/{id}/data-export", authenticated(r.privacyHandler.ExportData)).Methods("GET")
+	api.HandleFunc("/users/{id}/erasure", authenticated(r.privacyHandler.EraseUser)).Methods("POST")
+	api.HandleFunc("/users/{id}/devices", authenticated(r.loginActivityHandler.GetDevices)).Methods("GET")
+	api.HandleFunc("/users/{id}/logins", authenticated(r.loginActivityHandler.GetLoginHistory)).Methods("GET")
+	api.HandleFunc("/auth/login", r.userHandler.Login).Methods("POST")
+	api.HandleFunc("/auth/oidc/{provider}/login", r.oidcHandler.Login).Methods("GET")
+	api.HandleFunc("/auth/oidc/{provider}/callback", r.oidcHandler.Callback).Methods("GET")
+	api.HandleFunc("/auth/login/2fa", r.twoFactorHandler.CompleteLogin).Methods("POST")
+	api.HandleFunc("/auth/login/2fa/enroll", r.twoFactorHandler.StartLoginEnrollment).Methods("POST")
+	api.HandleFunc("/auth/login/2fa/activate", r.twoFactorHandler.CompleteLoginEnrollment).Methods("POST")
+	api.HandleFunc("/auth/2fa/enroll", r.twoFactorHandler.Enroll).Methods("POST")
+	api.HandleFunc("/auth/2fa/activate", r.twoFactorHandler.Activate).Methods("POST")
+	api.HandleFunc("/auth/2fa/disable", r.twoFactorHandler.Disable).Methods("POST")
+	api.HandleFunc("/auth/2fa/recovery-codes", r.twoFactorHandler.RegenerateRecoveryCodes).Methods("POST")
+	api.HandleFunc("/auth/password-reset/request", r.accountHandler.RequestPasswordReset).Methods("POST")
+	api.HandleFunc("/auth/password-reset/confirm", r.accountHandler.ResetPassword).Methods("POST")
+	api.HandleFunc("/auth/email-verification/request", r.accountHandler.RequestEmailVerification).Methods("POST")
+	api.HandleFunc("/auth/email-verification/confirm", r.accountHandler.VerifyEmail).Methods("POST")
+
+	api.HandleFunc("/admin/users/{id}/unlock", requires(domain.PermissionUsersWrite, r.userHandler.UnlockUser)).Methods("POST")
+	api.HandleFunc("/admin/users/{id}/impersonate", requires(domain.PermissionImpersonate, r.impersonationHandler.Impersonate)).Methods("POST")
+
+	api.HandleFunc("/admin/roles", requires(domain.PermissionRolesManage, r.roleHandler.ListRoles)).Methods("GET")
+	api.HandleFunc("/admin/users/{id}/roles", requires(domain.PermissionRolesManage, r.roleHandler.GetUserRoles)).Methods("GET")
+	api.HandleFunc("/admin/users/{id}/roles", requires(domain.PermissionRolesManage, r.roleHandler.GrantRole)).Methods("POST")
+	api.HandleFunc("/admin/users/{id}/roles/{role}", requires(domain.PermissionRolesManage, r.roleHandler.RevokeRole)).Methods("DELETE")
+
+	api.HandleFunc("/admin/audit", requires(domain.PermissionAuditRead, r.auditHandler.ListEntries)).Methods("GET")
+	api.HandleFunc("/admin/audit/verify", requires(domain.PermissionAuditRead, r.auditHandler.VerifyChain)).Methods("GET")
+	api.HandleFunc("/admin/api-usage", requires(domain.PermissionAuditRead, r.apiUsageHandler.GetUsage)).Methods("GET")
+
+	api.HandleFunc("/admin/keys", requires(domain.PermissionKeysManage, r.apiKeyHandler.ListKeys)).Methods("GET")
+	api.HandleFunc("/admin/keys", requires(domain.PermissionKeysManage, r.apiKeyHandler.CreateKey)).Methods("POST")
+	api.HandleFunc("/admin/keys/{id}/rotate", requires(domain.PermissionKeysManage, r.apiKeyHandler.RotateKey)).Methods("POST")
+	api.HandleFunc("/admin/keys/{id}", requires(domain.PermissionKeysManage, r.apiKeyHandler.RevokeKey)).Methods("DELETE")
 }
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/service"
+	"encoding/json"
+	"net/http"
+)
+
+type ITwoFactorHandler interface {
+	CompleteLogin(w http.ResponseWriter, r *http.Request)
+	StartLoginEnrollment(w http.ResponseWriter, r *http.Request)
+	CompleteLoginEnrollment(w http.ResponseWriter, r *http.Request)
+	Enroll(w http.ResponseWriter, r *http.Request)
+	Activate(w http.ResponseWriter, r *http.Request)
+	Disable(w http.ResponseWriter, r *http.Request)
+	RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request)
+}
+
+type TwoFactorHandler struct {
+	userService      service.IUserService
+	twoFactorService service.ITwoFactorService
+}
+
+func NewTwoFactorHandler(userService service.IUserService, twoFactorService service.ITwoFactorService) ITwoFactorHandler {
+	return &TwoFactorHandler{
+		userService:      userService,
+		twoFactorService: twoFactorService,
+	}
+}
+
+type twoFactorInput struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type enrollmentCompletedResponse struct {
+	Token         string   `json:"token"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *TwoFactorHandler) CompleteLogin(w http.ResponseWriter, r *http.Request) {
+	var input twoFactorInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	token, err := h.userService.CompleteTwoFactorLogin(r.Context(), input.ChallengeToken, input.Code)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{Token: token})
+}
+
+func (h *TwoFactorHandler) StartLoginEnrollment(w http.ResponseWriter, r *http.Request) {
+	var input twoFactorInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	enrollment, err := h.userService.StartTwoFactorEnrollment(r.Context(), input.ChallengeToken)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+func (h *TwoFactorHandler) CompleteLoginEnrollment(w http.ResponseWriter, r *http.Request) {
+	var input twoFactorInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	token, recoveryCodes, err := h.userService.CompleteTwoFactorEnrollment(r.Context(), input.ChallengeToken, input.Code)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollmentCompletedResponse{Token: token, RecoveryCodes: recoveryCodes})
+}
+
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r, "authentication required")
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(r.Context(), principal.UserID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+func (h *TwoFactorHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r, "authentication required")
+		return
+	}
+
+	var input twoFactorInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.Activate(r.Context(), principal.UserID, input.Code)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r, "authentication required")
+		return
+	}
+
+	var input twoFactorInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), principal.UserID, input.Code); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r, "authentication required")
+		return
+	}
+
+	var input twoFactorInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), principal.UserID, input.Code)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
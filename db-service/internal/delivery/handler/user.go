@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"awesomeProject22/db-service/internal/delivery/dto"
 	"awesomeProject22/db-service/internal/domain"
 	"awesomeProject22/db-service/internal/service"
 	"encoding/json"
@@ -14,8 +15,11 @@ type IUserHandler interface {
 	GetUser(w http.ResponseWriter, r *http.Request)
 	CreateUser(w http.ResponseWriter, r *http.Request)
 	UpdateUser(w http.ResponseWriter, r *http.Request)
+	PatchUser(w http.ResponseWriter, r *http.Request)
 	DeleteUser(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
+	UnlockUser(w http.ResponseWriter, r *http.Request)
+	BatchUsers(w http.ResponseWriter, r *http.Request)
 }
 
 type UserHandler struct {
@@ -29,109 +33,155 @@ func NewUserHandler(userService service.IUserService) IUserHandler {
 }
 
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userService.GetAll(r.Context())
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	var response interface{}
+	if apiVersionFromContext(r.Context()) == APIVersion2 {
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		users, total, err := h.userService.GetPage(r.Context(), limit, offset)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		response = dto.ToUserPage(users, total, limit, offset)
+	} else {
+		users, err := h.userService.GetAll(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		response = dto.ToUserResponses(users)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users)
+	json.NewEncoder(w).Encode(response)
 }
 
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid user ID")
 		return
 	}
 
 	user, err := h.userService.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(userResponse(r, user))
 }
 
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	var userInput struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		IsAdmin  bool   `json:"is_admin"`
-	}
+	var userInput dto.CreateUserRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(w, r, &userInput); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	user := &domain.User{
-		Username: userInput.Username,
-		Email:    userInput.Email,
-		IsAdmin:  userInput.IsAdmin,
-	}
+	user := userInput.ToDomain()
 
 	if err := h.userService.Create(r.Context(), user, userInput.Password); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(userResponse(r, user))
 }
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid user ID")
 		return
 	}
 
-	var userInput struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password,omitempty"`
-		IsAdmin  bool   `json:"is_admin"`
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var userInput dto.UpdateUserRequest
+
+	if err := decodeJSON(w, r, &userInput); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
 	currentUser, err := h.userService.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
-	currentUser.Username = userInput.Username
-	currentUser.Email = userInput.Email
-	currentUser.IsAdmin = userInput.IsAdmin
+	userInput.ApplyTo(currentUser)
+	currentUser.Version = version
 
 	if err := h.userService.Update(r.Context(), currentUser, userInput.Password != "", userInput.Password); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
+	setETag(w, currentUser.Version)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(currentUser)
+	json.NewEncoder(w).Encode(userResponse(r, currentUser))
+}
+
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	mediaType, patch, err := readPatch(w, r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	user, err := h.userService.Patch(r.Context(), id, version, mediaType, patch)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, user.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse(r, user))
 }
 
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid user ID")
 		return
 	}
 
-	if err := h.userService.Delete(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.userService.Delete(r.Context(), id, version); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -139,22 +189,81 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
-	var loginInput struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
+	var loginInput dto.LoginRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&loginInput); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(w, r, &loginInput); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	token, err := h.userService.Authenticate(r.Context(), loginInput.Username, loginInput.Password)
+	result, err := h.userService.Authenticate(r.Context(), loginInput.Username, loginInput.Password)
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeBadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	if err := h.userService.Unlock(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) BatchUsers(w http.ResponseWriter, r *http.Request) {
+	var batchInput dto.UserBatchRequest
+
+	if err := decodeJSON(w, r, &batchInput); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	operations := make([]service.UserBatchOperation, len(batchInput.Items))
+	for i, item := range batchInput.Items {
+		version, err := batchVersion(i, item.Op, item.Version)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		switch item.Op {
+		case domain.BatchCreate:
+			err = validateBatchItem(i, &dto.CreateUserRequest{Username: item.Username, Email: item.Email, Password: item.Password})
+		case domain.BatchUpdate:
+			err = validateBatchItem(i, &dto.UpdateUserRequest{Username: item.Username, Email: item.Email, Password: item.Password})
+		}
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		operations[i] = service.UserBatchOperation{
+			Op:       item.Op,
+			ID:       item.ID,
+			Version:  version,
+			Username: item.Username,
+			Email:    item.Email,
+			Password: item.Password,
+			IsAdmin:  item.IsAdmin,
+		}
+	}
+
+	results, err := h.userService.ApplyBatch(r.Context(), operations)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeBatchResults(w, results)
 }
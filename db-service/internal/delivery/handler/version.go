@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"awesomeProject22/db-service/internal/delivery/dto"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/service"
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	APIVersion1 = "v1"
+	APIVersion2 = "v2"
+)
+
+// apiVersions lists the served API versions, oldest first. Unversioned /api
+// paths keep serving v1 for existing integrations.
+var apiVersions = []string{APIVersion1, APIVersion2}
+
+// VersionPolicy announces the retirement of an API version through the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers.
+type VersionPolicy struct {
+	DeprecatedAt time.Time
+	Sunset       time.Time
+}
+
+func (p VersionPolicy) Deprecated() bool {
+	return !p.DeprecatedAt.IsZero()
+}
+
+type apiVersionKey struct{}
+
+func withAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+func apiVersionFromContext(ctx context.Context) string {
+	if version, ok := ctx.Value(apiVersionKey{}).(string); ok {
+		return version
+	}
+	return APIVersion1
+}
+
+func successorVersion(version string) string {
+	for i, candidate := range apiVersions[:len(apiVersions)-1] {
+		if candidate == version {
+			return apiVersions[i+1]
+		}
+	}
+	return ""
+}
+
+// APIVersion tags requests with the API version that serves them, counts them
+// and announces the deprecation of the version.
+func (m *Middleware) APIVersion(version string, policy VersionPolicy) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy.Deprecated() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", policy.DeprecatedAt.Unix()))
+				if !policy.Sunset.IsZero() {
+					w.Header().Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if successor := successorVersion(version); successor != "" {
+					w.Header().Add("Link", fmt.Sprintf(`</api/%s>; rel="successor-version"`, successor))
+				}
+			}
+
+			if m.usageService != nil {
+				m.usageService.Record(r.Context(), version)
+			}
+
+			next.ServeHTTP(w, r.WithContext(withAPIVersion(r.Context(), version)))
+		})
+	}
+}
+
+// pageParams reads the limit and offset of a v2 list.
+func pageParams(r *http.Request) (int, int, error) {
+	limit, offset := dto.DefaultPageLimit, 0
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > dto.MaxPageLimit {
+			return 0, 0, fmt.Errorf("%w: limit must be between 1 and %d", service.ErrInvalidInput, dto.MaxPageLimit)
+		}
+		limit = parsed
+	}
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("%w: offset must not be negative", service.ErrInvalidInput)
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
+
+func bookResponse(r *http.Request, book *domain.Book) interface{} {
+	if apiVersionFromContext(r.Context()) == APIVersion2 {
+		return dto.ToBookResponseV2(book)
+	}
+	return dto.ToBookResponse(book)
+}
+
+func userResponse(r *http.Request, user *domain.User) interface{} {
+	if apiVersionFromContext(r.Context()) == APIVersion2 {
+		return dto.ToUserResponseV2(user)
+	}
+	return dto.ToUserResponse(user)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditEntityBook   = "book"
+	AuditEntityUser   = "user"
+	AuditEntityRole   = "user_role"
+	AuditEntityAPIKey = "api_key"
+)
+
+type AuditEntry struct {
+	ID         int64                  `json:"id" db:"id"`
+	OccurredAt time.Time              `json:"occurred_at" db:"occurred_at"`
+	ActorID    *uuid.UUID             `json:"actor_id,omitempty" db:"actor_id"`
+	APIKeyID   *uuid.UUID             `json:"api_key_id,omitempty" db:"api_key_id"`
+	OnBehalfOf *uuid.UUID             `json:"on_behalf_of,omitempty" db:"on_behalf_of"`
+	Action     string                 `json:"action" db:"action"`
+	EntityType string                 `json:"entity_type,omitempty" db:"entity_type"`
+	EntityID   string                 `json:"entity_id,omitempty" db:"entity_id"`
+	Changes    map[string]interface{} `json:"changes" db:"changes"`
+	Method     string                 `json:"method,omitempty" db:"method"`
+	Route      string                 `json:"route,omitempty" db:"route"`
+	Status     int                    `json:"status,omitempty" db:"status"`
+	IP         string                 `json:"ip,omitempty" db:"ip"`
+	RequestID  string                 `json:"request_id,omitempty" db:"request_id"`
+	PrevHash   string                 `json:"prev_hash,omitempty" db:"prev_hash"`
+	Hash       string                 `json:"hash,omitempty" db:"hash"`
+}
+
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	OnBehalfOf *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditVerification struct {
+	Valid         bool  `json:"valid"`
+	Checked       int64 `json:"checked"`
+	FirstBrokenID int64 `json:"first_broken_id,omitempty"`
+}
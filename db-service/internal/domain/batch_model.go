@@ -0,0 +1,28 @@
+package domain
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// MaxBatchSize limits the items of one batch request.
+const MaxBatchSize = 1000
+
+// BookBatchItem is one write of a book batch. Book holds the new state, or
+// only the ID and version for a delete. Current is the stored book an update
+// or delete starts from.
+type BookBatchItem struct {
+	Op      string
+	Book    *Book
+	Current *Book
+}
+
+// UserBatchItem is one write of a user batch. PasswordChanged on an update
+// replaces the password with User.PasswordHash.
+type UserBatchItem struct {
+	Op              string
+	User            *User
+	Current         *User
+	PasswordChanged bool
+}
@@ -0,0 +1,11 @@
+package domain
+
+// IdempotencyRecord remembers a request sent with an Idempotency-Key. It is
+// pending until the first request completes and then holds its response.
+type IdempotencyRecord struct {
+	Fingerprint string              `json:"fingerprint"`
+	Completed   bool                `json:"completed"`
+	Status      int                 `json:"status,omitempty"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+}
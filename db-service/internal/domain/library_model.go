@@ -1,23 +1,32 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+// User fields tagged audit:"personal" are recorded in the audit log only as
+// changed, never with their values, since the log cannot be erased.
 type User struct {
-	ID           uuid.UUID `json:"id" db:"id"`
-	Username     string    `json:"username" db:"username"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"password_hash" db:"password_hash"`
-	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	Username      string     `json:"username" db:"username" audit:"personal"`
+	Email         string     `json:"email" db:"email" audit:"personal"`
+	PasswordHash  string     `json:"-" db:"password_hash"`
+	IsAdmin       bool       `json:"is_admin" db:"is_admin"`
+	EmailVerified bool       `json:"email_verified" db:"email_verified"`
+	Version       int64      `json:"version" db:"version"`
+	ErasedAt      *time.Time `json:"erased_at,omitempty" db:"erased_at"`
 }
 
 type Book struct {
-	ID     uuid.UUID `json:"id" db:"id"`
-	Genre  string    `json:"genre" db:"genre"`
-	Name   string    `json:"name" db:"name"`
-	Author string    `json:"author" db:"author"`
-	Year   int       `json:"year" db:"year"`
+	ID        uuid.UUID `json:"id" db:"id"`
+	Genre     string    `json:"genre" db:"genre" validate:"required,max=64"`
+	Name      string    `json:"name" db:"name" validate:"required,max=255"`
+	Author    string    `json:"author" db:"author" validate:"required,max=255"`
+	Year      int       `json:"year" db:"year" validate:"year"`
+	Version   int64     `json:"version" db:"version"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type UserBook struct {
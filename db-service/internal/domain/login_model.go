@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LoginReasonNewDevice  = "new_device"
+	LoginReasonNewNetwork = "new_network"
+)
+
+type LoginRecord struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	IP          string    `json:"ip" db:"ip"`
+	Network     string    `json:"network" db:"network"`
+	UserAgent   string    `json:"user_agent" db:"user_agent"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`
+	Suspicious  bool      `json:"suspicious" db:"suspicious"`
+	Reasons     []string  `json:"reasons" db:"reasons"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type KnownDevice struct {
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`
+	UserAgent   string    `json:"user_agent" db:"user_agent"`
+	LastIP      string    `json:"last_ip" db:"last_ip"`
+	FirstSeenAt time.Time `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"time"
+)
+
+type DataExport struct {
+	User           User            `json:"user"`
+	Roles          []Role          `json:"roles"`
+	Loans          []Book          `json:"loans"`
+	SecurityEvents []SecurityEvent `json:"security_events"`
+	Identities     []UserIdentity  `json:"identities"`
+	LoginHistory   []LoginRecord   `json:"login_history"`
+	Devices        []KnownDevice   `json:"devices"`
+	TwoFactor      *TwoFactor      `json:"two_factor,omitempty"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
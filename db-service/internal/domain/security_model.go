@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SecurityEventPrivilegeChanged         = "privilege.changed"
+	SecurityEventPrivilegeDenied          = "privilege.denied"
+	SecurityEventRoleGranted              = "role.granted"
+	SecurityEventRoleRevoked              = "role.revoked"
+	SecurityEventAccountUnlocked          = "account.unlocked"
+	SecurityEventPasswordReset            = "password.reset"
+	SecurityEventEmailVerified            = "email.verified"
+	SecurityEventTwoFactorEnabled         = "2fa.enabled"
+	SecurityEventTwoFactorDisabled        = "2fa.disabled"
+	SecurityEventRecoveryCodeUsed         = "2fa.recovery_code_used"
+	SecurityEventRecoveryCodesRegenerated = "2fa.recovery_codes_regenerated"
+	SecurityEventDataExported             = "user.data_exported"
+	SecurityEventUserErased               = "user.erased"
+	SecurityEventImpersonation            = "impersonation.started"
+	SecurityEventSuspiciousLogin          = "login.suspicious"
+)
+
+type SecurityEvent struct {
+	ID           uuid.UUID              `json:"id" db:"id"`
+	EventType    string                 `json:"event_type" db:"event_type"`
+	ActorID      *uuid.UUID             `json:"actor_id,omitempty" db:"actor_id"`
+	TargetUserID *uuid.UUID             `json:"target_user_id,omitempty" db:"target_user_id"`
+	Details      map[string]interface{} `json:"details" db:"details"`
+	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
+}
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+type UserToken struct {
+	TokenHash string     `json:"-" db:"token_hash"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Purpose   string     `json:"purpose" db:"purpose"`
+	Email     string     `json:"email,omitempty" db:"email"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type TwoFactor struct {
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`
+	Enabled      bool       `json:"enabled" db:"enabled"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+type APIUsage struct {
+	Version  string       `json:"version"`
+	Requests int64        `json:"requests"`
+	Daily    []DailyUsage `json:"daily"`
+}
+
+type DailyUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
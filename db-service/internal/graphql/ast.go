@@ -0,0 +1,90 @@
+package graphql
+
+// Document is a parsed GraphQL request document.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+type VariableDefinition struct {
+	Name       string
+	Type       *TypeRef
+	Default    interface{}
+	HasDefault bool
+}
+
+// TypeRef is a type as written in a variable definition: a named type, or a
+// list of Elem when Name is empty.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+// Selection is a *FieldNode, *FragmentSpread or *InlineFragment.
+type Selection interface{}
+
+type FieldNode struct {
+	Alias        string
+	Name         string
+	Arguments    []*ArgumentNode
+	Directives   []*Directive
+	SelectionSet []Selection
+	Location     Location
+}
+
+// ResponseKey is the alias of the field, or its name when it has none.
+func (f *FieldNode) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type ArgumentNode struct {
+	Name  string
+	Value interface{}
+}
+
+type Directive struct {
+	Name      string
+	Arguments []*ArgumentNode
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Location   Location
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+// Variable refers to a variable of the operation inside an argument value.
+type Variable string
+
+// EnumValue is an unquoted name used as a value.
+type EnumValue string
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
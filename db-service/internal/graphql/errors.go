@@ -0,0 +1,51 @@
+package graphql
+
+import "errors"
+
+const (
+	CodeParseFailed      = "GRAPHQL_PARSE_FAILED"
+	CodeValidationFailed = "GRAPHQL_VALIDATION_FAILED"
+	CodeBadUserInput     = "BAD_USER_INPUT"
+	CodeInternal         = "INTERNAL_SERVER_ERROR"
+)
+
+// Error is an entry of the errors list of a response. Path is set for errors
+// raised while resolving a field.
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code, message string, locations ...Location) *Error {
+	return &Error{
+		Message:    message,
+		Locations:  locations,
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+func syntaxError(location Location, message string) *Error {
+	return newError(CodeParseFailed, "Syntax Error: "+message, location)
+}
+
+func validationError(message string, locations ...Location) *Error {
+	return newError(CodeValidationFailed, message, locations...)
+}
+
+// ErrorFormatter turns an error returned by a resolver into the message and
+// extensions clients see, so the caller decides what is safe to expose.
+type ErrorFormatter func(err error) *Error
+
+func defaultFormatError(err error) *Error {
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		return &Error{Message: gqlErr.Message, Extensions: gqlErr.Extensions}
+	}
+	return newError(CodeInternal, err.Error())
+}
@@ -0,0 +1,556 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const (
+	DefaultMaxDepth      = 10
+	DefaultMaxComplexity = 1000
+	// DefaultListSize is the item count assumed for list fields without a
+	// ListSize estimate.
+	DefaultListSize = 10
+
+	maxCost = math.MaxInt32
+)
+
+type Params struct {
+	Schema        *Schema
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	// MaxDepth limits the nesting of fields, MaxComplexity the estimated
+	// number of fields resolved. Zero applies the defaults.
+	MaxDepth      int
+	MaxComplexity int
+	FormatError   ErrorFormatter
+}
+
+// Response is the result of a request. Data is nil when the request was
+// rejected before execution, e.g. for a syntax error or a limit.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Execute runs a query. Fields resolve level by level: every field of a level
+// is resolved before the thunks they returned are forced, so loaders see the
+// keys of the whole level at once.
+//
+// An error while resolving a field leaves it null, even when its type is
+// non-null; errors do not propagate to the parent.
+func Execute(ctx context.Context, params Params) *Response {
+	e, err := prepare(ctx, params)
+	if err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+
+	data := newOrderedMap()
+	e.run(job{object: params.Schema.Query, selections: [][]Selection{e.operation.SelectionSet}, result: data})
+	return &Response{Data: data, Errors: e.errors}
+}
+
+type executor struct {
+	ctx         context.Context
+	schema      *Schema
+	operation   *Operation
+	fragments   map[string]*Fragment
+	variables   map[string]interface{}
+	arguments   map[*FieldNode]map[string]interface{}
+	formatError ErrorFormatter
+	errors      []*Error
+	next        []job
+}
+
+// job resolves the selections of one object value into result.
+type job struct {
+	object     *Object
+	source     interface{}
+	selections [][]Selection
+	result     *orderedMap
+	path       []interface{}
+}
+
+type fieldGroup struct {
+	key   string
+	nodes []*FieldNode
+}
+
+type pendingField struct {
+	thunk  Thunk
+	def    *Field
+	group  fieldGroup
+	result *orderedMap
+	path   []interface{}
+}
+
+func prepare(ctx context.Context, params Params) (*executor, *Error) {
+	doc, err := Parse(params.Query)
+	if err != nil {
+		var gqlErr *Error
+		if errors.As(err, &gqlErr) {
+			return nil, gqlErr
+		}
+		return nil, syntaxError(Location{}, err.Error())
+	}
+
+	operation, gqlErr := selectOperation(doc, params.OperationName)
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	if operation.Type != "query" {
+		return nil, validationError(fmt.Sprintf("%s operations are not supported.", operation.Type))
+	}
+
+	e := &executor{
+		ctx:         ctx,
+		schema:      params.Schema,
+		operation:   operation,
+		fragments:   doc.Fragments,
+		arguments:   map[*FieldNode]map[string]interface{}{},
+		formatError: params.FormatError,
+	}
+	if e.formatError == nil {
+		e.formatError = defaultFormatError
+	}
+	if e.variables, gqlErr = coerceVariables(operation, params.Variables); gqlErr != nil {
+		return nil, gqlErr
+	}
+
+	maxDepth, maxComplexity := params.MaxDepth, params.MaxComplexity
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if maxComplexity <= 0 {
+		maxComplexity = DefaultMaxComplexity
+	}
+	complexity, depth, gqlErr := e.analyze(params.Schema.Query, operation.SelectionSet, 1, map[string]bool{})
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	if depth > maxDepth {
+		return nil, validationError(fmt.Sprintf("The query has a depth of %d, which exceeds the limit of %d.", depth, maxDepth))
+	}
+	if complexity > maxComplexity {
+		return nil, validationError(fmt.Sprintf("The query has a complexity of %d, which exceeds the limit of %d.", complexity, maxComplexity))
+	}
+	return e, nil
+}
+
+func selectOperation(doc *Document, name string) (*Operation, *Error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, validationError("An operation name is required when the document holds several operations.")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, operation := range doc.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, validationError(fmt.Sprintf("Unknown operation named %q.", name))
+}
+
+func coerceVariables(operation *Operation, values map[string]interface{}) (map[string]interface{}, *Error) {
+	variables := map[string]interface{}{}
+	for _, definition := range operation.Variables {
+		if _, ok := variables[definition.Name]; ok {
+			return nil, validationError(fmt.Sprintf("There can be only one variable named \"$%s\".", definition.Name))
+		}
+		typ, err := inputType(definition.Type)
+		if err != nil {
+			return nil, validationError(fmt.Sprintf("Variable \"$%s\": %v.", definition.Name, err))
+		}
+
+		value, ok := values[definition.Name]
+		if !ok {
+			if !definition.HasDefault {
+				if _, nonNull := typ.(*NonNull); nonNull {
+					return nil, newError(CodeBadUserInput, fmt.Sprintf("Variable \"$%s\" of required type %s was not provided.", definition.Name, typ))
+				}
+				continue
+			}
+			value = definition.Default
+		}
+		coerced, err := coerceInput(typ, value)
+		if err != nil {
+			return nil, newError(CodeBadUserInput, fmt.Sprintf("Variable \"$%s\" got an invalid value: %v.", definition.Name, err))
+		}
+		variables[definition.Name] = coerced
+	}
+	return variables, nil
+}
+
+// analyze validates the selections against the schema and coerces the field
+// arguments. It returns the complexity, where every field costs one and the
+// selections below a list count once per expected item, and the depth.
+func (e *executor) analyze(object *Object, selections []Selection, depth int, spreads map[string]bool) (int, int, *Error) {
+	complexity, maxDepth := 0, depth
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *FieldNode:
+			if ok, err := e.included(s.Directives); err != nil {
+				return 0, 0, err
+			} else if !ok {
+				continue
+			}
+			cost, fieldDepth, err := e.analyzeField(object, s, depth, spreads)
+			if err != nil {
+				return 0, 0, err
+			}
+			complexity = addCost(complexity, cost)
+			maxDepth = max(maxDepth, fieldDepth)
+		case *FragmentSpread:
+			if ok, err := e.included(s.Directives); err != nil {
+				return 0, 0, err
+			} else if !ok {
+				continue
+			}
+			fragment, ok := e.fragments[s.Name]
+			if !ok {
+				return 0, 0, validationError(fmt.Sprintf("Unknown fragment %q.", s.Name), s.Location)
+			}
+			if spreads[s.Name] {
+				return 0, 0, validationError(fmt.Sprintf("Cannot spread fragment %q within itself.", s.Name), s.Location)
+			}
+			if fragment.TypeCondition != object.Name {
+				return 0, 0, validationError(fmt.Sprintf("Fragment %q cannot be spread here as objects of type %q can never be of type %q.", s.Name, object.Name, fragment.TypeCondition), s.Location)
+			}
+			spreads[s.Name] = true
+			cost, fragmentDepth, err := e.analyze(object, fragment.SelectionSet, depth, spreads)
+			delete(spreads, s.Name)
+			if err != nil {
+				return 0, 0, err
+			}
+			complexity = addCost(complexity, cost)
+			maxDepth = max(maxDepth, fragmentDepth)
+		case *InlineFragment:
+			if ok, err := e.included(s.Directives); err != nil {
+				return 0, 0, err
+			} else if !ok {
+				continue
+			}
+			if s.TypeCondition != "" && s.TypeCondition != object.Name {
+				return 0, 0, validationError(fmt.Sprintf("Fragment cannot be spread here as objects of type %q can never be of type %q.", object.Name, s.TypeCondition))
+			}
+			cost, fragmentDepth, err := e.analyze(object, s.SelectionSet, depth, spreads)
+			if err != nil {
+				return 0, 0, err
+			}
+			complexity = addCost(complexity, cost)
+			maxDepth = max(maxDepth, fragmentDepth)
+		}
+	}
+	return complexity, maxDepth, nil
+}
+
+func (e *executor) analyzeField(object *Object, node *FieldNode, depth int, spreads map[string]bool) (int, int, *Error) {
+	if node.Name == "__typename" {
+		if node.SelectionSet != nil {
+			return 0, 0, validationError(`Field "__typename" must not have a selection since type "String!" has no subfields.`, node.Location)
+		}
+		return 0, depth, nil
+	}
+	def, ok := object.Fields[node.Name]
+	if !ok {
+		return 0, 0, validationError(fmt.Sprintf("Cannot query field %q on type %q.", node.Name, object.Name), node.Location)
+	}
+	args, err := e.coerceArguments(def, node)
+	if err != nil {
+		return 0, 0, err
+	}
+	e.arguments[node] = args
+
+	child, isObject := namedType(def.Type).(*Object)
+	if !isObject {
+		if node.SelectionSet != nil {
+			return 0, 0, validationError(fmt.Sprintf("Field %q must not have a selection since type %q has no subfields.", node.Name, def.Type), node.Location)
+		}
+		return 1, depth, nil
+	}
+	if node.SelectionSet == nil {
+		return 0, 0, validationError(fmt.Sprintf("Field %q of type %q must have a selection of subfields.", node.Name, def.Type), node.Location)
+	}
+
+	childCost, childDepth, err := e.analyze(child, node.SelectionSet, depth+1, spreads)
+	if err != nil {
+		return 0, 0, err
+	}
+	if isList(def.Type) {
+		size := DefaultListSize
+		if def.ListSize != nil {
+			size = def.ListSize(args)
+		}
+		childCost = multiplyCost(childCost, size)
+	}
+	return addCost(1, childCost), childDepth, nil
+}
+
+func addCost(a, b int) int {
+	return min(a+b, maxCost)
+}
+
+func multiplyCost(cost, factor int) int {
+	if factor <= 0 || cost <= 0 {
+		return 0
+	}
+	if cost > maxCost/factor {
+		return maxCost
+	}
+	return cost * factor
+}
+
+func (e *executor) coerceArguments(def *Field, node *FieldNode) (map[string]interface{}, *Error) {
+	args := map[string]interface{}{}
+	for _, argument := range node.Arguments {
+		argDef, ok := def.Args[argument.Name]
+		if !ok {
+			return nil, validationError(fmt.Sprintf("Unknown argument %q on field %q.", argument.Name, node.Name), node.Location)
+		}
+		value, ok := e.resolveValue(argument.Value)
+		if !ok {
+			continue
+		}
+		coerced, err := coerceInput(argDef.Type, value)
+		if err != nil {
+			return nil, newError(CodeBadUserInput, fmt.Sprintf("Argument %q of field %q has an invalid value: %v.", argument.Name, node.Name, err), node.Location)
+		}
+		args[argument.Name] = coerced
+	}
+	for name, argDef := range def.Args {
+		if _, ok := args[name]; ok {
+			continue
+		}
+		if argDef.Default != nil {
+			args[name] = argDef.Default
+		} else if _, nonNull := argDef.Type.(*NonNull); nonNull {
+			return nil, validationError(fmt.Sprintf("Field %q argument %q of type %q is required, but it was not provided.", node.Name, name, argDef.Type), node.Location)
+		}
+	}
+	return args, nil
+}
+
+// resolveValue replaces variables in an argument value. It reports false when
+// the value is a variable that was not provided, which leaves the argument
+// unset.
+func (e *executor) resolveValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case Variable:
+		resolved, ok := e.variables[string(v)]
+		return resolved, ok
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i], _ = e.resolveValue(item)
+		}
+		return list, true
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			if resolved, ok := e.resolveValue(item); ok {
+				object[name] = resolved
+			}
+		}
+		return object, true
+	}
+	return value, true
+}
+
+// included evaluates @skip and @include.
+func (e *executor) included(directives []*Directive) (bool, *Error) {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			return false, validationError(fmt.Sprintf("Unknown directive \"@%s\".", directive.Name))
+		}
+		var condition interface{}
+		for _, argument := range directive.Arguments {
+			if argument.Name != "if" {
+				return false, validationError(fmt.Sprintf("Unknown argument %q on directive \"@%s\".", argument.Name, directive.Name))
+			}
+			condition, _ = e.resolveValue(argument.Value)
+		}
+		value, ok := condition.(bool)
+		if !ok {
+			return false, newError(CodeBadUserInput, fmt.Sprintf("Directive \"@%s\" requires a Boolean \"if\" argument.", directive.Name))
+		}
+		if directive.Name == "skip" && value || directive.Name == "include" && !value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// collectFields flattens fragments and groups the fields by response key, so
+// fields requested twice resolve once with their selections merged.
+func (e *executor) collectFields(object *Object, selectionSets [][]Selection) []fieldGroup {
+	var groups []fieldGroup
+	index := map[string]int{}
+	var collect func(selections []Selection)
+	collect = func(selections []Selection) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *FieldNode:
+				if ok, _ := e.included(s.Directives); !ok {
+					continue
+				}
+				key := s.ResponseKey()
+				if i, ok := index[key]; ok {
+					groups[i].nodes = append(groups[i].nodes, s)
+					continue
+				}
+				index[key] = len(groups)
+				groups = append(groups, fieldGroup{key: key, nodes: []*FieldNode{s}})
+			case *FragmentSpread:
+				if ok, _ := e.included(s.Directives); ok {
+					collect(e.fragments[s.Name].SelectionSet)
+				}
+			case *InlineFragment:
+				if ok, _ := e.included(s.Directives); ok && (s.TypeCondition == "" || s.TypeCondition == object.Name) {
+					collect(s.SelectionSet)
+				}
+			}
+		}
+	}
+	for _, selections := range selectionSets {
+		collect(selections)
+	}
+	return groups
+}
+
+func (e *executor) run(root job) {
+	level := []job{root}
+	for len(level) > 0 {
+		e.next = nil
+		var pending []*pendingField
+		for _, j := range level {
+			for _, group := range e.collectFields(j.object, j.selections) {
+				node := group.nodes[0]
+				if node.Name == "__typename" {
+					j.result.set(group.key, j.object.Name)
+					continue
+				}
+
+				field := &pendingField{
+					def:    j.object.Fields[node.Name],
+					group:  group,
+					result: j.result,
+					path:   appendPath(j.path, group.key),
+				}
+				j.result.set(group.key, nil)
+				value, err := field.def.Resolve(e.ctx, j.source, e.arguments[node])
+				if e.settle(field, value, err) {
+					pending = append(pending, field)
+				}
+			}
+		}
+
+		for len(pending) > 0 {
+			forced := pending
+			pending = nil
+			for _, field := range forced {
+				value, err := field.thunk()
+				if e.settle(field, value, err) {
+					pending = append(pending, field)
+				}
+			}
+		}
+		level = e.next
+	}
+}
+
+// settle stores the value of a field, or keeps the field pending when the
+// value is another thunk.
+func (e *executor) settle(field *pendingField, value interface{}, err error) bool {
+	if err != nil {
+		e.fieldError(err, field.group.nodes, field.path)
+		return false
+	}
+	if thunk, ok := value.(Thunk); ok {
+		field.thunk = thunk
+		return true
+	}
+	completed, err := e.complete(field.def.Type, field.group.nodes, field.path, value)
+	if err != nil {
+		e.fieldError(err, field.group.nodes, field.path)
+		return false
+	}
+	field.result.set(field.group.key, completed)
+	return false
+}
+
+func (e *executor) complete(typ Type, nodes []*FieldNode, path []interface{}, value interface{}) (interface{}, error) {
+	if nonNull, ok := typ.(*NonNull); ok {
+		completed, err := e.complete(nonNull.OfType, nodes, path, value)
+		if err == nil && completed == nil {
+			err = newError(CodeInternal, "Cannot return null for non-nullable field.")
+		}
+		return completed, err
+	}
+	if isNil(value) {
+		return nil, nil
+	}
+
+	switch t := typ.(type) {
+	case *List:
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			return nil, newError(CodeInternal, fmt.Sprintf("Expected a list for %s.", t))
+		}
+		list := make([]interface{}, items.Len())
+		for i := range list {
+			itemPath := appendPath(path, i)
+			item, err := e.complete(t.OfType, nodes, itemPath, items.Index(i).Interface())
+			if err != nil {
+				e.fieldError(err, nodes, itemPath)
+				continue
+			}
+			list[i] = item
+		}
+		return list, nil
+	case *Scalar:
+		serialized, err := t.Serialize(value)
+		if err != nil {
+			return nil, newError(CodeInternal, err.Error())
+		}
+		return serialized, nil
+	case *Object:
+		result := newOrderedMap()
+		selections := make([][]Selection, len(nodes))
+		for i, node := range nodes {
+			selections[i] = node.SelectionSet
+		}
+		e.next = append(e.next, job{object: t, source: value, selections: selections, result: result, path: path})
+		return result, nil
+	}
+	return nil, newError(CodeInternal, fmt.Sprintf("%s is not an output type.", typ))
+}
+
+func (e *executor) fieldError(err error, nodes []*FieldNode, path []interface{}) {
+	formatted := e.formatError(err)
+	formatted.Locations = []Location{nodes[0].Location}
+	formatted.Path = path
+	e.errors = append(e.errors, formatted)
+}
+
+func appendPath(path []interface{}, segment interface{}) []interface{} {
+	extended := make([]interface{}, len(path), len(path)+1)
+	copy(extended, path)
+	return append(extended, segment)
+}
+
+// isNil reports null values. A nil slice is an empty list, not null.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
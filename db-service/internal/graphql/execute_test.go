@@ -0,0 +1,309 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testAuthor struct {
+	ID   string
+	Name string
+}
+
+type testBook struct {
+	ID       string
+	Title    string
+	AuthorID string
+}
+
+var testBooks = []testBook{
+	{ID: "1", Title: "Dune", AuthorID: "a"},
+	{ID: "2", Title: "Emma", AuthorID: "b"},
+	{ID: "3", Title: "Persuasion", AuthorID: "b"},
+}
+
+type authorLoaderKey struct{}
+
+// newTestSchema serves books whose authors are loaded in batches by the loader
+// found in the context.
+func newTestSchema() *Schema {
+	author := &Object{Name: "Author", Fields: map[string]*Field{
+		"name": {Type: &NonNull{OfType: String}, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testAuthor).Name, nil
+		}},
+	}}
+	book := &Object{Name: "Book", Fields: map[string]*Field{
+		"id": {Type: &NonNull{OfType: ID}, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(testBook).ID, nil
+		}},
+		"title": {Type: String, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(testBook).Title, nil
+		}},
+		"author": {Type: author, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			loader := ctx.Value(authorLoaderKey{}).(*Loader[string, *testAuthor])
+			return loader.Load(source.(testBook).AuthorID), nil
+		}},
+	}}
+	book.Fields["related"] = &Field{Type: &List{OfType: book}, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return testBooks, nil
+	}}
+
+	query := &Object{Name: "Query", Fields: map[string]*Field{
+		"books": {
+			Type: &NonNull{OfType: &List{OfType: &NonNull{OfType: book}}},
+			Args: map[string]*Argument{"first": {Type: Int, Default: 10}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return testBooks[:min(args["first"].(int), len(testBooks))], nil
+			},
+			ListSize: func(args map[string]interface{}) int { return args["first"].(int) },
+		},
+		"book": {
+			Type: book,
+			Args: map[string]*Argument{"id": {Type: &NonNull{OfType: ID}}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				for _, b := range testBooks {
+					if b.ID == args["id"] {
+						return b, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+		"echo": {
+			Type: &List{OfType: Int},
+			Args: map[string]*Argument{"values": {Type: &List{OfType: Int}}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return args["values"], nil
+			},
+		},
+		"failing": {Type: String, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("database is down")
+		}},
+		"missing": {Type: &NonNull{OfType: Int}, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return nil, nil
+		}},
+		"big": {Type: Int, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return int64(1) << 40, nil
+		}},
+	}}
+
+	return &Schema{Query: query}
+}
+
+func executeTest(t *testing.T, params Params) (*Response, [][]string) {
+	t.Helper()
+
+	var batches [][]string
+	params.Schema = newTestSchema()
+	authors := map[string]*testAuthor{"a": {ID: "a", Name: "Herbert"}, "b": {ID: "b", Name: "Austen"}}
+	ctx := context.Background()
+	loader := NewLoader(ctx, func(ctx context.Context, keys []string) []Result[*testAuthor] {
+		batches = append(batches, keys)
+		results := make([]Result[*testAuthor], len(keys))
+		for i, key := range keys {
+			results[i] = Result[*testAuthor]{Value: authors[key]}
+		}
+		return results
+	})
+	return Execute(context.WithValue(ctx, authorLoaderKey{}, loader), params), batches
+}
+
+func responseJSON(t *testing.T, response *Response) string {
+	t.Helper()
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("error encoding response: %v", err)
+	}
+	return string(data)
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			name:  "fields in requested order",
+			query: `{ books(first: 2) { title id } }`,
+			want:  `{"data":{"books":[{"title":"Dune","id":"1"},{"title":"Emma","id":"2"}]}}`,
+		},
+		{
+			name:  "aliases and typename",
+			query: `{ first: book(id: "1") { __typename name: title } second: book(id: 2) { title } }`,
+			want:  `{"data":{"first":{"__typename":"Book","name":"Dune"},"second":{"title":"Emma"}}}`,
+		},
+		{
+			name:  "null object",
+			query: `{ book(id: "9") { title } }`,
+			want:  `{"data":{"book":null}}`,
+		},
+		{
+			name:  "merged selections",
+			query: `{ book(id: "1") { id } book(id: "1") { title } }`,
+			want:  `{"data":{"book":{"id":"1","title":"Dune"}}}`,
+		},
+		{
+			name:  "fragments",
+			query: `query { book(id: "2") { ...Parts ... on Book { id } } } fragment Parts on Book { title }`,
+			want:  `{"data":{"book":{"title":"Emma","id":"2"}}}`,
+		},
+		{
+			name:      "variables and defaults",
+			query:     `query Q($id: ID! = "3", $skip: Boolean!) { book(id: $id) { id title @skip(if: $skip) } }`,
+			variables: map[string]interface{}{"skip": true},
+			want:      `{"data":{"book":{"id":"3"}}}`,
+		},
+		{
+			name:      "operation name",
+			query:     `query A { book(id: "1") { id } } query B { book(id: "2") { id } }`,
+			operation: "B",
+			want:      `{"data":{"book":{"id":"2"}}}`,
+		},
+		{
+			name:      "list variable from JSON",
+			query:     `query ($values: [Int]) { echo(values: $values) }`,
+			variables: map[string]interface{}{"values": []interface{}{1.0, json.Number("2")}},
+			want:      `{"data":{"echo":[1,2]}}`,
+		},
+		{
+			name:  "single value as list",
+			query: `{ echo(values: 7) }`,
+			want:  `{"data":{"echo":[7]}}`,
+		},
+		{
+			name:  "resolver error",
+			query: `{ failing book(id: "1") { id } }`,
+			want: `{"data":{"failing":null,"book":{"id":"1"}},"errors":[{"message":"database is down",` +
+				`"locations":[{"line":1,"column":3}],"path":["failing"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}]}`,
+		},
+		{
+			name:  "null for non-null field",
+			query: `{ missing }`,
+			want: `{"data":{"missing":null},"errors":[{"message":"Cannot return null for non-nullable field.",` +
+				`"locations":[{"line":1,"column":3}],"path":["missing"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}]}`,
+		},
+		{
+			name:  "Int out of range",
+			query: `{ big }`,
+			want: `{"data":{"big":null},"errors":[{"message":"Int cannot represent 1099511627776",` +
+				`"locations":[{"line":1,"column":3}],"path":["big"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, _ := executeTest(t, Params{Query: tt.query, OperationName: tt.operation, Variables: tt.variables})
+			if got := responseJSON(t, response); got != tt.want {
+				t.Errorf("response = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteBatchesLoaderKeysPerLevel(t *testing.T) {
+	response, batches := executeTest(t, Params{Query: `{ books { author { name } related { author { name } } } }`})
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors %v", response.Errors[0])
+	}
+
+	if len(batches) != 1 || strings.Join(batches[0], ",") != "a,b" {
+		t.Errorf("batches = %v, want the distinct keys of all levels loaded once", batches)
+	}
+	if got := responseJSON(t, response); !strings.HasPrefix(got, `{"data":{"books":[{"author":{"name":"Herbert"},"related":[{"author":{"name":"Herbert"}}`) {
+		t.Errorf("response = %s", got)
+	}
+}
+
+func TestExecuteRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		depth     int
+		limit     int
+		code      string
+		message   string
+	}{
+		{name: "syntax error", query: `{ book(`, code: CodeParseFailed},
+		{name: "mutation", query: `mutation { book(id: "1") { id } }`, code: CodeValidationFailed,
+			message: "mutation operations are not supported."},
+		{name: "several operations", query: `query A { big } query B { big }`, code: CodeValidationFailed},
+		{name: "unknown operation", query: `query A { big }`, operation: "B", code: CodeValidationFailed},
+		{name: "unknown field", query: `{ title }`, code: CodeValidationFailed,
+			message: `Cannot query field "title" on type "Query".`},
+		{name: "unknown argument", query: `{ big(x: 1) }`, code: CodeValidationFailed},
+		{name: "missing required argument", query: `{ book { id } }`, code: CodeValidationFailed},
+		{name: "missing selection", query: `{ book(id: "1") }`, code: CodeValidationFailed},
+		{name: "selection on scalar", query: `{ big { id } }`, code: CodeValidationFailed},
+		{name: "unknown fragment", query: `{ book(id: "1") { ...Missing } }`, code: CodeValidationFailed},
+		{name: "fragment cycle", query: `{ book(id: "1") { ...A } } fragment A on Book { related { ...A } }`, code: CodeValidationFailed},
+		{name: "fragment on other type", query: `{ ...A } fragment A on Book { id }`, code: CodeValidationFailed},
+		{name: "unknown directive", query: `{ big @deprecated }`, code: CodeValidationFailed},
+		{name: "invalid argument", query: `{ book(id: 1.5) { id } }`, code: CodeBadUserInput},
+		{name: "missing variable", query: `query ($id: ID!) { book(id: $id) { id } }`, code: CodeBadUserInput},
+		{name: "invalid variable", query: `query ($first: Int) { books(first: $first) { id } }`,
+			variables: map[string]interface{}{"first": "ten"}, code: CodeBadUserInput},
+		{name: "unknown variable type", query: `query ($b: Book) { big }`, code: CodeValidationFailed},
+		{name: "depth limit", query: `{ book(id: "1") { related { related { id } } } }`, depth: 3, code: CodeValidationFailed,
+			message: "The query has a depth of 4, which exceeds the limit of 3."},
+		{name: "default depth limit", query: `{ book(id: "1") ` + strings.Repeat("{ related ", DefaultMaxDepth) + "{ id }" +
+			strings.Repeat(" }", DefaultMaxDepth) + " }", code: CodeValidationFailed},
+		{name: "complexity limit", query: `{ books(first: 5) { id title } }`, limit: 10, code: CodeValidationFailed,
+			message: "The query has a complexity of 11, which exceeds the limit of 10."},
+		{name: "complexity of nested lists", query: `{ book(id: "1") { related { related { related { id } } } } }`, limit: 1000,
+			code: CodeValidationFailed, message: "The query has a complexity of 1112, which exceeds the limit of 1000."},
+		{name: "complexity does not overflow", query: `{ books(first: 2147483647) { related { related { related { id } } } } }`,
+			code: CodeValidationFailed, message: fmt.Sprintf("The query has a complexity of %d, which exceeds the limit of %d.", maxCost, DefaultMaxComplexity)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, batches := executeTest(t, Params{Query: tt.query, OperationName: tt.operation, Variables: tt.variables,
+				MaxDepth: tt.depth, MaxComplexity: tt.limit})
+			if response.Data != nil {
+				t.Errorf("data = %v, want the query rejected before execution", responseJSON(t, response))
+			}
+			if len(batches) > 0 {
+				t.Errorf("loader ran for a rejected query")
+			}
+			if len(response.Errors) != 1 {
+				t.Fatalf("errors = %v, want one", response.Errors)
+			}
+			err := response.Errors[0]
+			if code := err.Extensions["code"]; code != tt.code {
+				t.Errorf("code = %v, want %s (%s)", code, tt.code, err.Message)
+			}
+			if tt.message != "" && err.Message != tt.message {
+				t.Errorf("message = %q, want %q", err.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestExecuteWithinLimits(t *testing.T) {
+	response, _ := executeTest(t, Params{Query: `{ books(first: 5) { id title } }`, MaxDepth: 2, MaxComplexity: 11})
+	if response.Data == nil || len(response.Errors) > 0 {
+		t.Errorf("query at the limits was rejected: %v", responseJSON(t, response))
+	}
+}
+
+func TestExecuteFormatsErrors(t *testing.T) {
+	response, _ := executeTest(t, Params{
+		Query: `{ failing }`,
+		FormatError: func(err error) *Error {
+			return &Error{Message: "hidden", Extensions: map[string]interface{}{"code": "CUSTOM"}}
+		},
+	})
+	if len(response.Errors) != 1 || response.Errors[0].Message != "hidden" || response.Errors[0].Extensions["code"] != "CUSTOM" {
+		t.Errorf("errors = %v, want the formatted error", responseJSON(t, response))
+	}
+}
@@ -0,0 +1,231 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+type lexer struct {
+	source string
+	pos    int
+	line   int
+	column int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: source, line: 1, column: 1}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.source); i++ {
+		if l.source[l.pos] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.pos++
+	}
+}
+
+// skipIgnored skips white space, commas, byte order marks and comments, which
+// carry no meaning in GraphQL.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.source) {
+		switch c := l.source[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.source[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	location := Location{Line: l.line, Column: l.column}
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, location: location}, nil
+	}
+
+	c := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunctuator, value: "...", location: location}, nil
+	case strings.ContainsRune("!$():=@[]{}|&", rune(c)):
+		l.advance(1)
+		return token{kind: tokenPunctuator, value: string(c), location: location}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.source[start:l.pos], location: location}, nil
+	case c == '-' || isDigit(c):
+		return l.number(location)
+	case c == '"':
+		return l.string(location)
+	}
+	return token{}, syntaxError(location, fmt.Sprintf("unexpected character %q", c))
+}
+
+func (l *lexer) number(location Location) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.source[l.pos] == '-' {
+		l.advance(1)
+	}
+	if !l.digits() {
+		return token{}, syntaxError(location, "invalid number")
+	}
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if !l.digits() {
+			return token{}, syntaxError(location, "invalid number")
+		}
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.advance(1)
+		}
+		if !l.digits() {
+			return token{}, syntaxError(location, "invalid number")
+		}
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || l.source[l.pos] == '.') {
+		return token{}, syntaxError(location, "invalid number")
+	}
+	return token{kind: kind, value: l.source[start:l.pos], location: location}, nil
+}
+
+func (l *lexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+		l.advance(1)
+	}
+	return l.pos > start
+}
+
+func (l *lexer) string(location Location) (token, error) {
+	if strings.HasPrefix(l.source[l.pos:], `"""`) {
+		return l.blockString(location)
+	}
+	l.advance(1)
+
+	var value strings.Builder
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return token{kind: tokenString, value: value.String(), location: location}, nil
+		case c == '\n' || c == '\r':
+			return token{}, syntaxError(location, "unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.source) {
+				return token{}, syntaxError(location, "unterminated string")
+			}
+			escape := l.source[l.pos+1]
+			switch escape {
+			case '"', '\\', '/':
+				value.WriteByte(escape)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if l.pos+6 > len(l.source) {
+					return token{}, syntaxError(location, "invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(l.source[l.pos+2:l.pos+6], 16, 32)
+				if err != nil {
+					return token{}, syntaxError(location, "invalid unicode escape")
+				}
+				value.WriteRune(rune(code))
+				l.advance(4)
+			default:
+				return token{}, syntaxError(location, fmt.Sprintf("invalid escape \\%c", escape))
+			}
+			l.advance(2)
+		default:
+			r, size := utf8.DecodeRuneInString(l.source[l.pos:])
+			value.WriteRune(r)
+			l.advance(size)
+		}
+	}
+	return token{}, syntaxError(location, "unterminated string")
+}
+
+// blockString reads a """ string. Common indentation is kept as written.
+func (l *lexer) blockString(location Location) (token, error) {
+	l.advance(3)
+	var value strings.Builder
+	for l.pos < len(l.source) {
+		switch {
+		case strings.HasPrefix(l.source[l.pos:], `"""`):
+			l.advance(3)
+			return token{kind: tokenString, value: strings.Trim(value.String(), "\n"), location: location}, nil
+		case strings.HasPrefix(l.source[l.pos:], `\"""`):
+			value.WriteString(`"""`)
+			l.advance(4)
+		default:
+			value.WriteByte(l.source[l.pos])
+			l.advance(1)
+		}
+	}
+	return token{}, syntaxError(location, "unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
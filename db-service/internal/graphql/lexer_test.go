@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"errors"
+	"testing"
+)
+
+func lexAll(source string) ([]token, error) {
+	l := newLexer(source)
+	var tokens []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		kinds  []tokenKind
+		values []string
+	}{
+		{"punctuators", "{ ... $x! }", []tokenKind{tokenPunctuator, tokenPunctuator, tokenPunctuator, tokenName, tokenPunctuator, tokenPunctuator},
+			[]string{"{", "...", "$", "x", "!", "}"}},
+		{"names", "_id book2 __typename", []tokenKind{tokenName, tokenName, tokenName}, []string{"_id", "book2", "__typename"}},
+		{"ignored tokens", "\ufeff a,\tb # comment\r\n c", []tokenKind{tokenName, tokenName, tokenName}, []string{"a", "b", "c"}},
+		{"integers", "0 -12 345", []tokenKind{tokenInt, tokenInt, tokenInt}, []string{"0", "-12", "345"}},
+		{"floats", "1.5 -0.25 1e10 2.5E-3", []tokenKind{tokenFloat, tokenFloat, tokenFloat, tokenFloat}, []string{"1.5", "-0.25", "1e10", "2.5E-3"}},
+		{"string escapes", `"a\"b\\c\/d\né"`, []tokenKind{tokenString}, []string{"a\"b\\c/d\né"}},
+		{"unicode string", `"Ёлка"`, []tokenKind{tokenString}, []string{"Ёлка"}},
+		{"block string", "\"\"\"\n  line \"quoted\"\n  \\\"\"\"\n\"\"\"", []tokenKind{tokenString}, []string{"  line \"quoted\"\n  \"\"\""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := lexAll(tt.source)
+			if err != nil {
+				t.Fatalf("error lexing: %v", err)
+			}
+			if len(tokens) != len(tt.kinds) {
+				t.Fatalf("got %d tokens %v, want %d", len(tokens), tokens, len(tt.kinds))
+			}
+			for i, token := range tokens {
+				if token.kind != tt.kinds[i] || token.value != tt.values[i] {
+					t.Errorf("token %d = (%d, %q), want (%d, %q)", i, token.kind, token.value, tt.kinds[i], tt.values[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLexerLocations(t *testing.T) {
+	tokens, err := lexAll("{\n  book {\n    id }\n}")
+	if err != nil {
+		t.Fatalf("error lexing: %v", err)
+	}
+
+	want := []Location{{1, 1}, {2, 3}, {2, 8}, {3, 5}, {3, 8}, {4, 1}}
+	for i, token := range tokens {
+		if token.location != want[i] {
+			t.Errorf("token %s at %v, want %v", token, token.location, want[i])
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"unexpected character", "?"},
+		{"lone minus", "-"},
+		{"missing fraction", "1."},
+		{"missing exponent", "1e"},
+		{"name after number", "12abc"},
+		{"second dot", "1.2.3"},
+		{"unterminated string", `"abc`},
+		{"newline in string", "\"ab\ncd\""},
+		{"invalid escape", `"\x"`},
+		{"short unicode escape", `"\u12"`},
+		{"invalid unicode escape", `"\u12zz"`},
+		{"unterminated block string", `"""abc`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := lexAll(tt.source)
+			var gqlErr *Error
+			if !errors.As(err, &gqlErr) || gqlErr.Extensions["code"] != CodeParseFailed {
+				t.Errorf("error = %v, want a syntax error", err)
+			}
+		})
+	}
+}
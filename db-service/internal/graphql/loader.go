@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+)
+
+type Result[V any] struct {
+	Value V
+	Err   error
+}
+
+// BatchFunc loads many keys at once. It returns one result per key, in the
+// order of keys.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) []Result[V]
+
+// Loader collects the keys requested while a level of a query resolves and
+// loads them with a single call of its BatchFunc once the first value is
+// needed. Results are kept for the life of the loader, which should be one
+// request. A Loader is not safe for concurrent use; Execute resolves fields on
+// one goroutine.
+type Loader[K comparable, V any] struct {
+	ctx     context.Context
+	batch   BatchFunc[K, V]
+	pending []K
+	queued  map[K]bool
+	results map[K]Result[V]
+}
+
+func NewLoader[K comparable, V any](ctx context.Context, batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:     ctx,
+		batch:   batch,
+		queued:  map[K]bool{},
+		results: map[K]Result[V]{},
+	}
+}
+
+// Load queues key and returns a thunk for its value, to be returned from a
+// resolver.
+func (l *Loader[K, V]) Load(key K) Thunk {
+	if _, ok := l.results[key]; !ok && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	return func() (interface{}, error) {
+		result, ok := l.results[key]
+		if !ok {
+			l.dispatch()
+			result = l.results[key]
+		}
+		return result.Value, result.Err
+	}
+}
+
+func (l *Loader[K, V]) dispatch() {
+	keys := l.pending
+	l.pending = nil
+	results := l.batch(l.ctx, keys)
+	for i, key := range keys {
+		delete(l.queued, key)
+		if len(results) != len(keys) {
+			l.results[key] = Result[V]{Err: fmt.Errorf("error loading batch: got %d results for %d keys", len(results), len(keys))}
+			continue
+		}
+		l.results[key] = results[i]
+	}
+}
@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseOperation(t *testing.T) {
+	doc, err := Parse(`
+		query Books($first: Int = 5, $ids: [ID!]!, $author: String) @include(if: true) {
+			list: books(first: $first, filter: {author: $author, genres: [SCIFI, "drama"]}) {
+				id
+				... on Book { title }
+				...BookParts @skip(if: false)
+			}
+			book(id: 1, price: 2.5, active: null, draft: false)
+		}
+		fragment BookParts on Book { year }`)
+	if err != nil {
+		t.Fatalf("error parsing: %v", err)
+	}
+
+	want := &Document{
+		Operations: []*Operation{{
+			Type: "query",
+			Name: "Books",
+			Variables: []*VariableDefinition{
+				{Name: "first", Type: &TypeRef{Name: "Int"}, Default: int64(5), HasDefault: true},
+				{Name: "ids", Type: &TypeRef{Elem: &TypeRef{Name: "ID", NonNull: true}, NonNull: true}},
+				{Name: "author", Type: &TypeRef{Name: "String"}},
+			},
+			Directives: []*Directive{{Name: "include", Arguments: []*ArgumentNode{{Name: "if", Value: true}}}},
+			SelectionSet: []Selection{
+				&FieldNode{
+					Alias: "list",
+					Name:  "books",
+					Arguments: []*ArgumentNode{
+						{Name: "first", Value: Variable("first")},
+						{Name: "filter", Value: map[string]interface{}{
+							"author": Variable("author"),
+							"genres": []interface{}{EnumValue("SCIFI"), "drama"},
+						}},
+					},
+					SelectionSet: []Selection{
+						&FieldNode{Name: "id", Location: Location{Line: 4, Column: 5}},
+						&InlineFragment{TypeCondition: "Book", SelectionSet: []Selection{
+							&FieldNode{Name: "title", Location: Location{Line: 5, Column: 19}},
+						}},
+						&FragmentSpread{Name: "BookParts", Location: Location{Line: 6, Column: 5},
+							Directives: []*Directive{{Name: "skip", Arguments: []*ArgumentNode{{Name: "if", Value: false}}}}},
+					},
+					Location: Location{Line: 3, Column: 4},
+				},
+				&FieldNode{
+					Name: "book",
+					Arguments: []*ArgumentNode{
+						{Name: "id", Value: int64(1)},
+						{Name: "price", Value: 2.5},
+						{Name: "active", Value: nil},
+						{Name: "draft", Value: false},
+					},
+					Location: Location{Line: 8, Column: 4},
+				},
+			},
+		}},
+		Fragments: map[string]*Fragment{
+			"BookParts": {Name: "BookParts", TypeCondition: "Book", SelectionSet: []Selection{
+				&FieldNode{Name: "year", Location: Location{Line: 10, Column: 32}},
+			}},
+		},
+	}
+
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("Parse returned an unexpected document:\n got %#v\nwant %#v", doc, want)
+	}
+}
+
+func TestParseShorthandQuery(t *testing.T) {
+	doc, err := Parse("{ a b }")
+	if err != nil {
+		t.Fatalf("error parsing: %v", err)
+	}
+	if len(doc.Operations) != 1 || doc.Operations[0].Type != "query" || doc.Operations[0].Name != "" ||
+		len(doc.Operations[0].SelectionSet) != 2 {
+		t.Errorf("unexpected operation %+v", doc.Operations)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		code   string
+	}{
+		{"empty document", "", CodeValidationFailed},
+		{"only a fragment", "fragment F on Book { id }", CodeValidationFailed},
+		{"duplicate fragment", "{ a } fragment F on Book { id } fragment F on Book { id }", CodeValidationFailed},
+		{"duplicate argument", "{ a(x: 1, x: 2) }", CodeValidationFailed},
+		{"type definition", "type Book { id: ID }", CodeParseFailed},
+		{"empty selection", "{ }", CodeParseFailed},
+		{"unclosed selection", "{ a { b }", CodeParseFailed},
+		{"empty arguments", "{ a() }", CodeParseFailed},
+		{"argument without value", "{ a(x:) }", CodeParseFailed},
+		{"variable in default", "query ($a: Int = $b) { a }", CodeParseFailed},
+		{"variable without type", "query ($a) { a }", CodeParseFailed},
+		{"unclosed list type", "query ($a: [Int) { a }", CodeParseFailed},
+		{"fragment named on", "{ a } fragment on on Book { id }", CodeParseFailed},
+		{"fragment without type", "{ a } fragment F { id }", CodeParseFailed},
+		{"unclosed list", "{ a(x: [1, 2) }", CodeParseFailed},
+		{"lexer error", "{ a(x: \"open) }", CodeParseFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.source)
+			var gqlErr *Error
+			if !errors.As(err, &gqlErr) {
+				t.Fatalf("error = %v, want a GraphQL error", err)
+			}
+			if code := gqlErr.Extensions["code"]; code != tt.code {
+				t.Errorf("code = %v, want %s (%s)", code, tt.code, gqlErr.Message)
+			}
+		})
+	}
+}
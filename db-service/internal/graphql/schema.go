@@ -0,0 +1,286 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Type is a *Scalar, *Object, *List or *NonNull.
+type Type interface {
+	String() string
+}
+
+// ResolveFunc computes a field from the value of its parent object. It may
+// return a Thunk to defer the work until every sibling field was resolved, so
+// loaders can batch their keys.
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Thunk is a value that is computed later. See ResolveFunc.
+type Thunk func() (interface{}, error)
+
+// Schema exposes the read side of the API. Only queries are supported.
+type Schema struct {
+	Query *Object
+}
+
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+// Field is a field of an object. ListSize estimates the number of items a list
+// field returns for the complexity limit; without it DefaultListSize is used.
+type Field struct {
+	Type     Type
+	Args     map[string]*Argument
+	Resolve  ResolveFunc
+	ListSize func(args map[string]interface{}) int
+}
+
+type Argument struct {
+	Type    Type
+	Default interface{}
+}
+
+type List struct {
+	OfType Type
+}
+
+func (l *List) String() string {
+	return "[" + l.OfType.String() + "]"
+}
+
+type NonNull struct {
+	OfType Type
+}
+
+func (n *NonNull) String() string {
+	return n.OfType.String() + "!"
+}
+
+// Scalar is a leaf type. Serialize turns a resolved value into its JSON
+// representation and ParseValue coerces an input value, which is a variable
+// decoded from JSON or a literal from the document.
+type Scalar struct {
+	Name       string
+	Serialize  func(value interface{}) (interface{}, error)
+	ParseValue func(value interface{}) (interface{}, error)
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+var (
+	Int = &Scalar{
+		Name:       "Int",
+		Serialize:  serializeInt,
+		ParseValue: parseInt,
+	}
+	Float = &Scalar{
+		Name:       "Float",
+		Serialize:  parseFloat,
+		ParseValue: parseFloat,
+	}
+	String = &Scalar{
+		Name:       "String",
+		Serialize:  serializeString,
+		ParseValue: parseString,
+	}
+	Boolean = &Scalar{
+		Name:       "Boolean",
+		Serialize:  parseBoolean,
+		ParseValue: parseBoolean,
+	}
+	ID = &Scalar{
+		Name:       "ID",
+		Serialize:  serializeString,
+		ParseValue: parseID,
+	}
+)
+
+var scalars = map[string]*Scalar{
+	Int.Name:     Int,
+	Float.Name:   Float,
+	String.Name:  String,
+	Boolean.Name: Boolean,
+	ID.Name:      ID,
+}
+
+func serializeInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		return serializeInt(int64(v))
+	case int32:
+		return v, nil
+	case int64:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("Int cannot represent %d", v)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("Int cannot represent %v", value)
+}
+
+func parseInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		if v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return parseInt(n)
+		}
+	}
+	return nil, fmt.Errorf("Int cannot represent %v", value)
+}
+
+func parseFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		if n, err := v.Float64(); err == nil {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("Float cannot represent %v", value)
+}
+
+func serializeString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return nil, fmt.Errorf("String cannot represent %v", value)
+}
+
+func parseString(value interface{}) (interface{}, error) {
+	if v, ok := value.(string); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("String cannot represent %v", value)
+}
+
+func parseBoolean(value interface{}) (interface{}, error) {
+	if v, ok := value.(bool); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("Boolean cannot represent %v", value)
+}
+
+func parseID(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatFloat(v, 'f', 0, 64), nil
+		}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return v.String(), nil
+		}
+	}
+	return nil, fmt.Errorf("ID cannot represent %v", value)
+}
+
+// coerceInput checks value against an input type and converts it to the Go
+// representation resolvers receive: int, float64, string, bool or a slice.
+func coerceInput(typ Type, value interface{}) (interface{}, error) {
+	switch t := typ.(type) {
+	case *NonNull:
+		if value == nil {
+			return nil, fmt.Errorf("expected a non-null %s", t.OfType)
+		}
+		return coerceInput(t.OfType, value)
+	case *List:
+		if value == nil {
+			return nil, nil
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			// A single value is accepted as a list of one item.
+			items = []interface{}{value}
+		}
+		coerced := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if coerced[i], err = coerceInput(t.OfType, item); err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return coerced, nil
+	case *Scalar:
+		if value == nil {
+			return nil, nil
+		}
+		return t.ParseValue(value)
+	}
+	return nil, fmt.Errorf("%s is not an input type", typ)
+}
+
+// inputType resolves the type of a variable definition.
+func inputType(ref *TypeRef) (Type, error) {
+	var typ Type
+	if ref.Elem != nil {
+		elem, err := inputType(ref.Elem)
+		if err != nil {
+			return nil, err
+		}
+		typ = &List{OfType: elem}
+	} else {
+		scalar, ok := scalars[ref.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown input type %q", ref.Name)
+		}
+		typ = scalar
+	}
+	if ref.NonNull {
+		typ = &NonNull{OfType: typ}
+	}
+	return typ, nil
+}
+
+func namedType(typ Type) Type {
+	for {
+		switch t := typ.(type) {
+		case *NonNull:
+			typ = t.OfType
+		case *List:
+			typ = t.OfType
+		default:
+			return typ
+		}
+	}
+}
+
+func isList(typ Type) bool {
+	if nonNull, ok := typ.(*NonNull); ok {
+		typ = nonNull.OfType
+	}
+	_, ok := typ.(*List)
+	return ok
+}
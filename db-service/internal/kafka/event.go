@@ -4,6 +4,7 @@ import (
 	"awesomeProject22/db-service/internal/domain"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,11 +16,19 @@ const (
 	BookCreated EventType = "book.created"
 	BookUpdated EventType = "book.updated"
 	BookDeleted EventType = "book.deleted"
+	BookPatched EventType = "book.patched"
 
 	UserCreated  EventType = "user.created"
 	UserUpdated  EventType = "user.updated"
 	UserDeleted  EventType = "user.deleted"
 	UserLoggedIn EventType = "user.logged_in"
+	UserErased   EventType = "user.erased"
+	UserPatched  EventType = "user.patched"
+
+	UserSuspiciousLogin EventType = "user.suspicious_login"
+
+	UserLoginFailed EventType = "user.login_failed"
+	UserLocked      EventType = "user.locked"
 )
 
 type Event struct {
@@ -38,20 +47,122 @@ func NewEvent(eventType EventType, payload interface{}) Event {
 	}
 }
 
+type BookPayload struct {
+	ID     uuid.UUID `json:"id"`
+	Genre  string    `json:"genre"`
+	Name   string    `json:"name"`
+	Author string    `json:"author"`
+	Year   int       `json:"year"`
+}
+
+type UserPayload struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	IsAdmin       bool      `json:"is_admin"`
+	EmailVerified bool      `json:"email_verified"`
+}
+
 type BookEvent struct {
-	Book domain.Book `json:"book"`
+	Book BookPayload `json:"book"`
 }
 
 type UserEvent struct {
-	User domain.User `json:"user"`
+	User UserPayload `json:"user"`
+}
+
+func NewBookPayload(book *domain.Book) BookPayload {
+	return BookPayload{
+		ID:     book.ID,
+		Genre:  book.Genre,
+		Name:   book.Name,
+		Author: book.Author,
+		Year:   book.Year,
+	}
+}
+
+func NewUserPayload(user *domain.User) UserPayload {
+	return UserPayload{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		IsAdmin:       user.IsAdmin,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+// PatchEvent carries only the fields a patch actually changed, with their new
+// values.
+type PatchEvent struct {
+	ID      uuid.UUID              `json:"id"`
+	Changes map[string]interface{} `json:"changes"`
+}
+
+// changedFields compares two payloads of the same type through their JSON
+// form and returns the new values of the fields that differ.
+func changedFields(before, after interface{}) (map[string]interface{}, error) {
+	var beforeFields, afterFields map[string]interface{}
+	for _, state := range []struct {
+		value  interface{}
+		fields *map[string]interface{}
+	}{{before, &beforeFields}, {after, &afterFields}} {
+		raw, err := json.Marshal(state.value)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, state.fields); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := map[string]interface{}{}
+	for field, value := range afterFields {
+		if !reflect.DeepEqual(beforeFields[field], value) {
+			changes[field] = value
+		}
+	}
+	return changes, nil
 }
 
 type LoginEvent struct {
-	UserID    uuid.UUID `json:"user_id"`
+	UserID            uuid.UUID `json:"user_id"`
+	Username          string    `json:"username"`
+	IP                string    `json:"ip,omitempty"`
+	UserAgent         string    `json:"user_agent,omitempty"`
+	DeviceFingerprint string    `json:"device_fingerprint,omitempty"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+type SuspiciousLoginEvent struct {
+	UserID            uuid.UUID `json:"user_id"`
+	Username          string    `json:"username"`
+	IP                string    `json:"ip,omitempty"`
+	Network           string    `json:"network,omitempty"`
+	UserAgent         string    `json:"user_agent,omitempty"`
+	DeviceFingerprint string    `json:"device_fingerprint"`
+	Reasons           []string  `json:"reasons"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+type UserErasedEvent struct {
+	UserID   uuid.UUID `json:"user_id"`
+	ErasedAt time.Time `json:"erased_at"`
+}
+
+type LoginFailedEvent struct {
 	Username  string    `json:"username"`
+	IP        string    `json:"ip,omitempty"`
+	Attempts  int64     `json:"attempts"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
+type UserLockedEvent struct {
+	Username    string    `json:"username"`
+	IP          string    `json:"ip,omitempty"`
+	LockedUntil time.Time `json:"locked_until"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
 func (e *Event) Serialize() ([]byte, error) {
 	data, err := json.Marshal(e)
 	if err != nil {
@@ -13,10 +13,16 @@ type IEventProducer interface {
 	PublishBookCreated(ctx context.Context, book *domain.Book) error
 	PublishBookUpdated(ctx context.Context, book *domain.Book) error
 	PublishBookDeleted(ctx context.Context, id uuid.UUID) error
+	PublishBookPatched(ctx context.Context, before, after *domain.Book) error
 	PublishUserCreated(ctx context.Context, user *domain.User) error
 	PublishUserUpdated(ctx context.Context, user *domain.User) error
 	PublishUserDeleted(ctx context.Context, id uuid.UUID) error
-	PublishUserLoggedIn(ctx context.Context, userId uuid.UUID, username string) error
+	PublishUserPatched(ctx context.Context, before, after *domain.User) error
+	PublishUserLoggedIn(ctx context.Context, username string, record *domain.LoginRecord) error
+	PublishSuspiciousLogin(ctx context.Context, username string, record *domain.LoginRecord) error
+	PublishUserErased(ctx context.Context, userId uuid.UUID, erasedAt time.Time) error
+	PublishUserLoginFailed(ctx context.Context, username, ip string, attempts int64) error
+	PublishUserLocked(ctx context.Context, username, ip string, lockedUntil time.Time) error
 }
 
 type EventProducer struct {
@@ -31,7 +37,7 @@ func NewEventProducer(client IKafkaClient) IEventProducer {
 
 func (p *EventProducer) PublishBookCreated(ctx context.Context, book *domain.Book) error {
 	payload := BookEvent{
-		Book: *book,
+		Book: NewBookPayload(book),
 	}
 
 	event := NewEvent(BookCreated, payload)
@@ -40,13 +46,23 @@ func (p *EventProducer) PublishBookCreated(ctx context.Context, book *domain.Boo
 
 func (p *EventProducer) PublishBookUpdated(ctx context.Context, book *domain.Book) error {
 	payload := BookEvent{
-		Book: *book,
+		Book: NewBookPayload(book),
 	}
 
 	event := NewEvent(BookUpdated, payload)
 	return p.publishEvent(ctx, event)
 }
 
+func (p *EventProducer) PublishBookPatched(ctx context.Context, before, after *domain.Book) error {
+	changes, err := changedFields(NewBookPayload(before), NewBookPayload(after))
+	if err != nil {
+		return fmt.Errorf("error computing book changes: %w", err)
+	}
+
+	event := NewEvent(BookPatched, PatchEvent{ID: after.ID, Changes: changes})
+	return p.publishEvent(ctx, event)
+}
+
 func (p *EventProducer) PublishBookDeleted(ctx context.Context, id uuid.UUID) error {
 	payload := map[string]string{
 		"id": id.String(),
@@ -57,11 +73,8 @@ func (p *EventProducer) PublishBookDeleted(ctx context.Context, id uuid.UUID) er
 }
 
 func (p *EventProducer) PublishUserCreated(ctx context.Context, user *domain.User) error {
-	safeUser := *user
-	safeUser.PasswordHash = ""
-
 	payload := UserEvent{
-		User: safeUser,
+		User: NewUserPayload(user),
 	}
 
 	event := NewEvent(UserCreated, payload)
@@ -69,17 +82,24 @@ func (p *EventProducer) PublishUserCreated(ctx context.Context, user *domain.Use
 }
 
 func (p *EventProducer) PublishUserUpdated(ctx context.Context, user *domain.User) error {
-	safeUser := *user
-	safeUser.PasswordHash = ""
-
 	payload := UserEvent{
-		User: safeUser,
+		User: NewUserPayload(user),
 	}
 
 	event := NewEvent(UserUpdated, payload)
 	return p.publishEvent(ctx, event)
 }
 
+func (p *EventProducer) PublishUserPatched(ctx context.Context, before, after *domain.User) error {
+	changes, err := changedFields(NewUserPayload(before), NewUserPayload(after))
+	if err != nil {
+		return fmt.Errorf("error computing user changes: %w", err)
+	}
+
+	event := NewEvent(UserPatched, PatchEvent{ID: after.ID, Changes: changes})
+	return p.publishEvent(ctx, event)
+}
+
 func (p *EventProducer) PublishUserDeleted(ctx context.Context, id uuid.UUID) error {
 	payload := map[string]string{
 		"id": id.String(),
@@ -89,14 +109,67 @@ func (p *EventProducer) PublishUserDeleted(ctx context.Context, id uuid.UUID) er
 	return p.publishEvent(ctx, event)
 }
 
-func (p *EventProducer) PublishUserLoggedIn(ctx context.Context, userId uuid.UUID, username string) error {
+func (p *EventProducer) PublishUserLoggedIn(ctx context.Context, username string, record *domain.LoginRecord) error {
 	payload := LoginEvent{
-		UserID:    userId,
+		UserID:            record.UserID,
+		Username:          username,
+		IP:                record.IP,
+		UserAgent:         record.UserAgent,
+		DeviceFingerprint: record.Fingerprint,
+		Timestamp:         record.CreatedAt,
+	}
+
+	event := NewEvent(UserLoggedIn, payload)
+	return p.publishEvent(ctx, event)
+}
+
+func (p *EventProducer) PublishSuspiciousLogin(ctx context.Context, username string, record *domain.LoginRecord) error {
+	payload := SuspiciousLoginEvent{
+		UserID:            record.UserID,
+		Username:          username,
+		IP:                record.IP,
+		Network:           record.Network,
+		UserAgent:         record.UserAgent,
+		DeviceFingerprint: record.Fingerprint,
+		Reasons:           record.Reasons,
+		Timestamp:         record.CreatedAt,
+	}
+
+	event := NewEvent(UserSuspiciousLogin, payload)
+	return p.publishEvent(ctx, event)
+}
+
+func (p *EventProducer) PublishUserErased(ctx context.Context, userId uuid.UUID, erasedAt time.Time) error {
+	payload := UserErasedEvent{
+		UserID:   userId,
+		ErasedAt: erasedAt,
+	}
+
+	event := NewEvent(UserErased, payload)
+	return p.publishEvent(ctx, event)
+}
+
+func (p *EventProducer) PublishUserLoginFailed(ctx context.Context, username, ip string, attempts int64) error {
+	payload := LoginFailedEvent{
 		Username:  username,
+		IP:        ip,
+		Attempts:  attempts,
 		Timestamp: time.Now(),
 	}
 
-	event := NewEvent(UserLoggedIn, payload)
+	event := NewEvent(UserLoginFailed, payload)
+	return p.publishEvent(ctx, event)
+}
+
+func (p *EventProducer) PublishUserLocked(ctx context.Context, username, ip string, lockedUntil time.Time) error {
+	payload := UserLockedEvent{
+		Username:    username,
+		IP:          ip,
+		LockedUntil: lockedUntil,
+		Timestamp:   time.Now(),
+	}
+
+	event := NewEvent(UserLocked, payload)
 	return p.publishEvent(ctx, event)
 }
 
@@ -0,0 +1,246 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "library"
+	testNonce    = "nonce-1"
+)
+
+// testIssuer serves a discovery document and a JWKS with the public halves of
+// its keys, and signs ID tokens with them.
+type testIssuer struct {
+	server     *httptest.Server
+	keys       map[string]*rsa.PrivateKey
+	jwksLoaded atomic.Int32
+}
+
+func newTestIssuer(t *testing.T, kids ...string) *testIssuer {
+	t.Helper()
+
+	issuer := &testIssuer{keys: map[string]*rsa.PrivateKey{}}
+	for _, kid := range kids {
+		issuer.addKey(t, kid)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:  issuer.server.URL,
+			JWKSURI: issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksLoaded.Add(1)
+		var keys []jsonWebKey
+		for kid, key := range issuer.keys {
+			keys = append(keys, jsonWebKey{
+				Kid: kid,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *testIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	i.keys[kid] = key
+}
+
+func (i *testIssuer) provider() IProvider {
+	return NewProvider(ProviderConfig{Name: "test", IssuerURL: i.server.URL, ClientID: testClientID}, i.server.Client())
+}
+
+func (i *testIssuer) claims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":                i.server.URL,
+		"sub":                "subject-1",
+		"aud":                testClientID,
+		"nonce":              testNonce,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Minute).Unix(),
+		"email":              "anna@example.com",
+		"email_verified":     true,
+		"preferred_username": "anna",
+		"groups":             []string{"admins", "staff"},
+	}
+}
+
+func (i *testIssuer) sign(t *testing.T, header, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	encode := func(value interface{}) string {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("error encoding token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	unsigned := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("error signing token: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	header := map[string]interface{}{"alg": "RS256", "kid": "key-1"}
+
+	tests := []struct {
+		name    string
+		header  map[string]interface{}
+		change  func(claims map[string]interface{})
+		key     *rsa.PrivateKey
+		token   string
+		wantErr bool
+	}{
+		{name: "valid"},
+		{name: "audience list", change: func(c map[string]interface{}) { c["aud"] = []string{"other", testClientID} }},
+		{name: "issuer with trailing slash", change: func(c map[string]interface{}) { c["iss"] = issuer.server.URL + "/" }},
+		{name: "expired within skew", change: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-clockSkew / 2).Unix() }},
+		{name: "single key without kid", header: map[string]interface{}{"alg": "RS256"}},
+		{name: "malformed", token: "a.b", wantErr: true},
+		{name: "algorithm none", header: map[string]interface{}{"alg": "none", "kid": "key-1"}, wantErr: true},
+		{name: "HS256", header: map[string]interface{}{"alg": "HS256", "kid": "key-1"}, wantErr: true},
+		{name: "unknown kid", header: map[string]interface{}{"alg": "RS256", "kid": "key-2"}, wantErr: true},
+		{name: "signed with other key", key: other, wantErr: true},
+		{name: "other issuer", change: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "missing subject", change: func(c map[string]interface{}) { delete(c, "sub") }, wantErr: true},
+		{name: "other audience", change: func(c map[string]interface{}) { c["aud"] = "other" }, wantErr: true},
+		{name: "missing audience", change: func(c map[string]interface{}) { delete(c, "aud") }, wantErr: true},
+		{name: "other nonce", change: func(c map[string]interface{}) { c["nonce"] = "nonce-2" }, wantErr: true},
+		{name: "missing nonce", change: func(c map[string]interface{}) { delete(c, "nonce") }, wantErr: true},
+		{name: "expired", change: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * clockSkew).Unix() }, wantErr: true},
+		{name: "missing expiry", change: func(c map[string]interface{}) { delete(c, "exp") }, wantErr: true},
+		{name: "issued in the future", change: func(c map[string]interface{}) { c["iat"] = time.Now().Add(2 * clockSkew).Unix() }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token
+			if token == "" {
+				claims := issuer.claims()
+				if tt.change != nil {
+					tt.change(claims)
+				}
+				tokenHeader, key := header, issuer.keys["key-1"]
+				if tt.header != nil {
+					tokenHeader = tt.header
+				}
+				if tt.key != nil {
+					key = tt.key
+				}
+				token = issuer.sign(t, tokenHeader, claims, key)
+			}
+
+			claims, err := issuer.provider().VerifyIDToken(context.Background(), token, testNonce)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("error = %v, want %v", err, ErrInvalidIDToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error verifying token: %v", err)
+			}
+			if claims.Subject != "subject-1" || claims.Email != "anna@example.com" || !claims.EmailVerified ||
+				claims.PreferredUsername != "anna" {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRefreshesRotatedKeys(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	provider := issuer.provider()
+	ctx := context.Background()
+
+	first := issuer.sign(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, issuer.claims(), issuer.keys["key-1"])
+	for i := 0; i < 2; i++ {
+		if _, err := provider.VerifyIDToken(ctx, first, testNonce); err != nil {
+			t.Fatalf("error verifying token: %v", err)
+		}
+	}
+	if loaded := issuer.jwksLoaded.Load(); loaded != 1 {
+		t.Errorf("JWKS loaded %d times, want it cached after the first time", loaded)
+	}
+
+	issuer.addKey(t, "key-2")
+	second := issuer.sign(t, map[string]interface{}{"alg": "RS256", "kid": "key-2"}, issuer.claims(), issuer.keys["key-2"])
+	if _, err := provider.VerifyIDToken(ctx, second, testNonce); err != nil {
+		t.Fatalf("error verifying token signed with a rotated key: %v", err)
+	}
+	if loaded := issuer.jwksLoaded.Load(); loaded != 2 {
+		t.Errorf("JWKS loaded %d times, want a refresh for the new kid", loaded)
+	}
+}
+
+func TestIDTokenClaimsStringValues(t *testing.T) {
+	claims := &IDTokenClaims{Raw: map[string]interface{}{
+		"single": "admins",
+		"list":   []interface{}{"admins", 7, "staff"},
+		"number": 7.0,
+	}}
+
+	tests := []struct {
+		claim string
+		want  []string
+	}{
+		{"single", []string{"admins"}},
+		{"list", []string{"admins", "staff"}},
+		{"number", nil},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.claim, func(t *testing.T) {
+			got := claims.StringValues(tt.claim)
+			if len(got) != len(tt.want) {
+				t.Fatalf("StringValues = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("StringValues = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type APIKeyRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) IAPIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		db: db,
+	}
+}
+
+const apiKeySelect = `SELECT id, name, prefix, key_hash, permissions, created_by, created_at,
+                             expires_at, last_used_at, rotated_at, revoked_at
+                      FROM api_keys`
+
+func (r *APIKeyRepositoryImpl) Create(ctx context.Context, key *domain.APIKey) error {
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+
+	query := `INSERT INTO api_keys (id, name, prefix, key_hash, permissions, created_by, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`
+	err := r.db.QueryRow(ctx, query,
+		key.ID, key.Name, key.Prefix, key.KeyHash, key.Permissions, key.CreatedBy, key.ExpiresAt).Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating api key: %w", mapWriteError(err))
+	}
+	return nil
+}
+
+func (r *APIKeyRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(ctx, apiKeySelect+` WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("api key with ID %s %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error requesting api key with ID %s: %w", id, err)
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepositoryImpl) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(ctx, apiKeySelect+` WHERE prefix = $1`, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("api key with prefix %s %w", prefix, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error requesting api key with prefix %s: %w", prefix, err)
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepositoryImpl) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := r.db.Query(ctx, apiKeySelect+` ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting list of api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning api key data: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing results: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepositoryImpl) Rotate(ctx context.Context, id uuid.UUID, prefix, keyHash string) error {
+	query := `UPDATE api_keys SET prefix = $1, key_hash = $2, rotated_at = NOW() WHERE id = $3 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, prefix, keyHash, id)
+	if err != nil {
+		return fmt.Errorf("error rotating api key with ID %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("active api key with ID %s %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *APIKeyRepositoryImpl) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error revoking api key with ID %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("active api key with ID %s %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *APIKeyRepositoryImpl) TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(ctx, query, usedAt, id)
+	if err != nil {
+		return fmt.Errorf("error updating last use of api key with ID %s: %w", id, err)
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.Permissions, &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RotatedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strings"
+	"time"
+)
+
+// auditChainLock serializes appends so that every chained entry sees the hash
+// of its predecessor.
+const auditChainLock = 7_424_511
+
+const auditSelect = `SELECT id, occurred_at, actor_id, api_key_id, on_behalf_of, action, entity_type, entity_id, changes,
+                            method, route, status, ip, request_id, prev_hash, hash
+                     FROM audit_log`
+
+type AuditRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) IAuditRepository {
+	return &AuditRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *AuditRepositoryImpl) Append(ctx context.Context, entry *domain.AuditEntry, chain bool) error {
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = time.Now()
+	}
+	entry.OccurredAt = entry.OccurredAt.UTC().Truncate(time.Microsecond)
+	if entry.Changes == nil {
+		entry.Changes = map[string]interface{}{}
+	}
+
+	changes, err := canonicalJSON(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("error serializing audit changes: %w", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting audit transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	entry.PrevHash, entry.Hash = "", ""
+	if chain {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, auditChainLock); err != nil {
+			return fmt.Errorf("error locking audit chain: %w", err)
+		}
+
+		err := tx.QueryRow(ctx, `SELECT hash FROM audit_log WHERE hash <> '' ORDER BY id DESC LIMIT 1`).Scan(&entry.PrevHash)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("error reading audit chain head: %w", err)
+		}
+
+		if entry.Hash, err = auditEntryHash(entry); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO audit_log (occurred_at, actor_id, api_key_id, on_behalf_of, action, entity_type, entity_id, changes,
+                                     method, route, status, ip, request_id, prev_hash, hash)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
+	err = tx.QueryRow(ctx, query,
+		entry.OccurredAt, entry.ActorID, entry.APIKeyID, entry.OnBehalfOf, entry.Action, entry.EntityType, entry.EntityID, changes,
+		entry.Method, entry.Route, entry.Status, entry.IP, entry.RequestID, entry.PrevHash, entry.Hash).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("error appending audit entry: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing audit entry: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditRepositoryImpl) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorID != nil {
+		addCondition("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.OnBehalfOf != nil {
+		addCondition("on_behalf_of = $%d", *filter.OnBehalfOf)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.EntityType != "" {
+		addCondition("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		addCondition("entity_id = $%d", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		addCondition("request_id = $%d", filter.RequestID)
+	}
+	if filter.From != nil {
+		addCondition("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("occurred_at < $%d", *filter.To)
+	}
+
+	query := auditSelect
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Verify walks the whole log in insertion order and recomputes every chained
+// hash. Entries written while chaining was disabled are skipped.
+func (r *AuditRepositoryImpl) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	rows, err := r.db.Query(ctx, auditSelect+` WHERE hash <> '' ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	result := &domain.AuditVerification{Valid: true}
+	previous := ""
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Checked++
+
+		hash, err := auditEntryHash(entry)
+		if err != nil {
+			return nil, err
+		}
+		if entry.PrevHash != previous || entry.Hash != hash {
+			result.Valid = false
+			result.FirstBrokenID = entry.ID
+			return result, nil
+		}
+		previous = entry.Hash
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after reading audit chain: %w", err)
+	}
+
+	return result, nil
+}
+
+func scanAuditEntry(rows pgx.Rows) (*domain.AuditEntry, error) {
+	var entry domain.AuditEntry
+	var changes []byte
+	err := rows.Scan(&entry.ID, &entry.OccurredAt, &entry.ActorID, &entry.APIKeyID, &entry.OnBehalfOf, &entry.Action, &entry.EntityType,
+		&entry.EntityID, &changes, &entry.Method, &entry.Route, &entry.Status, &entry.IP, &entry.RequestID,
+		&entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning audit entry: %w", err)
+	}
+	if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+		return nil, fmt.Errorf("error deserializing audit changes: %w", err)
+	}
+	entry.OccurredAt = entry.OccurredAt.UTC()
+	return &entry, nil
+}
+
+// auditEntryHash covers every stored column except the id and the hash itself.
+// Changes are hashed in canonical form because JSONB does not preserve the
+// original formatting.
+func auditEntryHash(entry *domain.AuditEntry) (string, error) {
+	changes, err := canonicalJSON(entry.Changes)
+	if err != nil {
+		return "", fmt.Errorf("error serializing audit changes: %w", err)
+	}
+
+	fields := []string{
+		entry.PrevHash,
+		entry.OccurredAt.UTC().Format(time.RFC3339Nano),
+		optionalUUID(entry.ActorID),
+		optionalUUID(entry.APIKeyID),
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		string(changes),
+		entry.Method,
+		entry.Route,
+		fmt.Sprint(entry.Status),
+		entry.IP,
+		entry.RequestID,
+	}
+	// Appended only when present so entries written before the column
+	// existed keep their original hash.
+	if entry.OnBehalfOf != nil {
+		fields = append(fields, entry.OnBehalfOf.String())
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("error encoding audit entry: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func optionalUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func canonicalJSON(value interface{}) ([]byte, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(decoded)
+}
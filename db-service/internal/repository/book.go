@@ -9,6 +9,7 @@ import (
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"strings"
+	"time"
 )
 
 type BookRepositoryImpl struct {
@@ -22,28 +23,32 @@ func NewBookRepository(db *pgxpool.Pool) IBookRepository {
 }
 
 func (r *BookRepositoryImpl) Create(ctx context.Context, book *domain.Book) error {
+	return createBook(ctx, r.db, book)
+}
+
+func createBook(ctx context.Context, db querier, book *domain.Book) error {
 	if book.ID == uuid.Nil {
 		book.ID = uuid.New()
 	}
 
-	query := `INSERT INTO books (id, genre, name, author, year) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(ctx, query,
-		book.ID, book.Genre, book.Name, book.Author, book.Year)
+	query := `INSERT INTO books (id, genre, name, author, year) VALUES ($1, $2, $3, $4, $5) RETURNING version, updated_at`
+	err := db.QueryRow(ctx, query,
+		book.ID, book.Genre, book.Name, book.Author, book.Year).Scan(&book.Version, &book.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("error creating book: %w", err)
+		return fmt.Errorf("error creating book: %w", mapWriteError(err))
 	}
 	return nil
 }
 
 func (r *BookRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain.Book, error) {
 	var book domain.Book
-	query := `SELECT id, genre, name, author, year FROM books WHERE id = $1`
+	query := `SELECT id, genre, name, author, year, version, updated_at FROM books WHERE id = $1`
 
 	err := r.db.QueryRow(ctx, query, id).Scan(
-		&book.ID, &book.Genre, &book.Name, &book.Author, &book.Year)
+		&book.ID, &book.Genre, &book.Name, &book.Author, &book.Year, &book.Version, &book.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("book with ID %s not found", id)
+			return nil, fmt.Errorf("book with ID %s %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("error requesting book with ID %s: %w", id, err)
 	}
@@ -54,63 +59,197 @@ func (r *BookRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain
 func (r *BookRepositoryImpl) GetAll(ctx context.Context, author, genre string) ([]*domain.Book, error) {
 	books := []*domain.Book{}
 
-	query := `SELECT id, genre, name, author, year FROM books`
+	where, params := bookFilter(author, genre)
+	query := `SELECT id, genre, name, author, year, version, updated_at FROM books` + where
+
+	rows, err := r.db.Query(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error while executing GetAll request: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var book domain.Book
+		err := rows.Scan(&book.ID, &book.Genre, &book.Name, &book.Author, &book.Year, &book.Version, &book.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning results: %w", err)
+		}
+		books = append(books, &book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error when processing results: %w", err)
+	}
+
+	return books, nil
+}
+
+// GetPage returns one page of the books matching the filters, ordered by name,
+// and the number of all matching books.
+func (r *BookRepositoryImpl) GetPage(ctx context.Context, author, genre string, limit, offset int) ([]*domain.Book, int, error) {
+	where, params := bookFilter(author, genre)
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM books`+where, params...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error counting books: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT id, genre, name, author, year, version, updated_at FROM books%s
+              ORDER BY name, id LIMIT $%d OFFSET $%d`, where, len(params)+1, len(params)+2)
+	books, err := r.queryBooks(ctx, query, append(params, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return books, total, nil
+}
+
+func bookFilter(author, genre string) (string, []interface{}) {
 	params := []interface{}{}
 	var conditions []string
-	paramIndex := 1
 
 	if author != "" {
-		conditions = append(conditions, fmt.Sprintf("author = $%d", paramIndex))
 		params = append(params, author)
-		paramIndex++
+		conditions = append(conditions, fmt.Sprintf("author = $%d", len(params)))
 	}
 
 	if genre != "" {
-		conditions = append(conditions, fmt.Sprintf("genre = $%d", paramIndex))
 		params = append(params, genre)
+		conditions = append(conditions, fmt.Sprintf("genre = $%d", len(params)))
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if len(conditions) == 0 {
+		return "", params
 	}
+	return " WHERE " + strings.Join(conditions, " AND "), params
+}
 
-	rows, err := r.db.Query(ctx, query, params...)
+// GetByIDs returns the books among ids that exist, in no particular order.
+func (r *BookRepositoryImpl) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Book, error) {
+	query := `SELECT id, genre, name, author, year, version, updated_at FROM books WHERE id = ANY($1::uuid[])`
+	return r.queryBooks(ctx, query, uuidStrings(ids))
+}
+
+func (r *BookRepositoryImpl) GetByAuthors(ctx context.Context, authors []string) ([]*domain.Book, error) {
+	query := `SELECT id, genre, name, author, year, version, updated_at FROM books WHERE author = ANY($1) ORDER BY name`
+	return r.queryBooks(ctx, query, authors)
+}
+
+func (r *BookRepositoryImpl) queryBooks(ctx context.Context, query string, args ...interface{}) ([]*domain.Book, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error while executing GetAll request: %w", err)
+		return nil, fmt.Errorf("error getting books: %w", err)
 	}
 	defer rows.Close()
 
+	books := []*domain.Book{}
 	for rows.Next() {
 		var book domain.Book
-		err := rows.Scan(&book.ID, &book.Genre, &book.Name, &book.Author, &book.Year)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning results: %w", err)
+		if err := rows.Scan(&book.ID, &book.Genre, &book.Name, &book.Author, &book.Year, &book.Version, &book.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning book: %w", err)
 		}
 		books = append(books, &book)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error when processing results: %w", err)
+		return nil, fmt.Errorf("error after processing books: %w", err)
 	}
 
 	return books, nil
 }
 
+// GetLoans returns the loans of the given books.
+func (r *BookRepositoryImpl) GetLoans(ctx context.Context, bookIDs []uuid.UUID) ([]domain.UserBook, error) {
+	query := `SELECT user_id, book_id FROM user_book WHERE book_id = ANY($1::uuid[])`
+	return queryLoans(ctx, r.db, query, uuidStrings(bookIDs))
+}
+
+// Update only succeeds while the stored version still equals book.Version, or
+// unconditionally for AnyVersion, and stores the incremented version in book.
 func (r *BookRepositoryImpl) Update(ctx context.Context, book *domain.Book) error {
-	query := `UPDATE books SET genre = $1, name = $2, author = $3, year = $4 WHERE id = $5`
-	_, err := r.db.Exec(ctx, query,
-		book.Genre, book.Name, book.Author, book.Year, book.ID)
+	return updateBook(ctx, r.db, book)
+}
+
+func updateBook(ctx context.Context, db querier, book *domain.Book) error {
+	query := `UPDATE books SET genre = $1, name = $2, author = $3, year = $4, version = version + 1, updated_at = NOW()
+              WHERE id = $5 AND ($6 = 0 OR version = $6) RETURNING version, updated_at`
+	err := db.QueryRow(ctx, query,
+		book.Genre, book.Name, book.Author, book.Year, book.ID, book.Version).Scan(&book.Version, &book.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("error updating book with ID %s: %w", book.ID, err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return bookVersionMismatch(ctx, db, book.ID)
+		}
+		return fmt.Errorf("error updating book with ID %s: %w", book.ID, mapWriteError(err))
 	}
 	return nil
 }
 
-func (r *BookRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM books WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+func (r *BookRepositoryImpl) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	return inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		return deleteBook(ctx, tx, id, version)
+	})
+}
+
+// deleteBook also moves the deletion time of the catalog, which the newest
+// updated_at of the remaining books cannot show.
+
+func deleteBook(ctx context.Context, db querier, id uuid.UUID, version int64) error {
+	query := `DELETE FROM books WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	tag, err := db.Exec(ctx, query, id, version)
 	if err != nil {
 		return fmt.Errorf("error deleting book with ID %s: %w", id, err)
 	}
+	if tag.RowsAffected() == 0 {
+		return bookVersionMismatch(ctx, db, id)
+	}
+
+	if _, err := db.Exec(ctx, `UPDATE book_catalog SET deleted_at = NOW()`); err != nil {
+		return fmt.Errorf("error recording deletion of book with ID %s: %w", id, err)
+	}
 	return nil
 }
+
+// LastModified returns the time of the latest change to the catalog: the
+// newest created or updated book, or the latest deletion.
+func (r *BookRepositoryImpl) LastModified(ctx context.Context) (time.Time, error) {
+	var lastModified time.Time
+	query := `SELECT GREATEST((SELECT MAX(updated_at) FROM books), deleted_at) FROM book_catalog`
+	if err := r.db.QueryRow(ctx, query).Scan(&lastModified); err != nil {
+		return time.Time{}, fmt.Errorf("error requesting last change of the catalog: %w", err)
+	}
+	return lastModified, nil
+}
+
+// ApplyBatch writes all items in one transaction. The first failing item rolls
+// everything back and is reported as a BatchItemError.
+func (r *BookRepositoryImpl) ApplyBatch(ctx context.Context, items []domain.BookBatchItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting book batch: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i, item := range items {
+		switch item.Op {
+		case domain.BatchCreate:
+			err = createBook(ctx, tx, item.Book)
+		case domain.BatchUpdate:
+			err = updateBook(ctx, tx, item.Book)
+		case domain.BatchDelete:
+			err = deleteBook(ctx, tx, item.Book.ID, item.Book.Version)
+		default:
+			err = fmt.Errorf("unknown batch operation %q", item.Op)
+		}
+		if err != nil {
+			return &BatchItemError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing book batch: %w", err)
+	}
+	return nil
+}
+
+func bookVersionMismatch(ctx context.Context, db querier, id uuid.UUID) error {
+	return staleOrMissing(ctx, db, `SELECT EXISTS (SELECT 1 FROM books WHERE id = $1)`, "book", id)
+}
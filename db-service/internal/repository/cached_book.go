@@ -5,6 +5,7 @@ import (
 	"awesomeProject22/db-service/internal/domain"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
@@ -125,9 +126,36 @@ func (r *CachedBookRepository) GetAll(ctx context.Context, author, genre string)
 	return books, nil
 }
 
+// GetPage reads pages from the database; caching every limit and offset a
+// client asks for would hardly ever hit.
+func (r *CachedBookRepository) GetPage(ctx context.Context, author, genre string, limit, offset int) ([]*domain.Book, int, error) {
+	return r.repo.GetPage(ctx, author, genre, limit, offset)
+}
+
+// GetByIDs, GetByAuthors and GetLoans serve the batched reads of the GraphQL
+// API, which already load many records per query, from the database.
+func (r *CachedBookRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Book, error) {
+	return r.repo.GetByIDs(ctx, ids)
+}
+
+func (r *CachedBookRepository) GetByAuthors(ctx context.Context, authors []string) ([]*domain.Book, error) {
+	return r.repo.GetByAuthors(ctx, authors)
+}
+
+func (r *CachedBookRepository) GetLoans(ctx context.Context, bookIDs []uuid.UUID) ([]domain.UserBook, error) {
+	return r.repo.GetLoans(ctx, bookIDs)
+}
+
+// LastModified is read from the database so that writes of other instances,
+// which do not invalidate anything here, are seen at once.
+func (r *CachedBookRepository) LastModified(ctx context.Context) (time.Time, error) {
+	return r.repo.LastModified(ctx)
+}
+
 func (r *CachedBookRepository) Update(ctx context.Context, book *domain.Book) error {
 	err := r.repo.Update(ctx, book)
 	if err != nil {
+		r.evictStale(ctx, book.ID, err)
 		return fmt.Errorf("error updating book in database: %w", err)
 	}
 
@@ -145,9 +173,10 @@ func (r *CachedBookRepository) Update(ctx context.Context, book *domain.Book) er
 	return nil
 }
 
-func (r *CachedBookRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	err := r.repo.Delete(ctx, id)
+func (r *CachedBookRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	err := r.repo.Delete(ctx, id, version)
 	if err != nil {
+		r.evictStale(ctx, id, err)
 		return fmt.Errorf("error deleting book from database: %w", err)
 	}
 
@@ -158,3 +187,51 @@ func (r *CachedBookRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// ApplyBatch invalidates the cache once the batch has committed: the books it
+// wrote and the lists filtered by their old or new author and genre.
+func (r *CachedBookRepository) ApplyBatch(ctx context.Context, items []domain.BookBatchItem) error {
+	if err := r.repo.ApplyBatch(ctx, items); err != nil {
+		return fmt.Errorf("error applying book batch in database: %w", err)
+	}
+
+	keys := map[string]bool{}
+	for _, item := range items {
+		for _, book := range []*domain.Book{item.Book, item.Current} {
+			if book == nil {
+				continue
+			}
+			keys[getBookKey(book.ID)] = true
+			if book.Author == "" && book.Genre == "" {
+				continue
+			}
+			keys[getBookListKey("", "")] = true
+			keys[getBookListKey(book.Author, "")] = true
+			keys[getBookListKey("", book.Genre)] = true
+			keys[getBookListKey(book.Author, book.Genre)] = true
+		}
+	}
+
+	staleKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		staleKeys = append(staleKeys, key)
+	}
+	if redisErr := r.redisClient.Delete(ctx, staleKeys...); redisErr != nil {
+		fmt.Printf("Error invalidating cache after book batch: %v\n", redisErr)
+	}
+
+	return nil
+}
+
+// evictStale drops the cached copy when a conditional write shows it is out
+// of date, so the client can fetch the current version and retry.
+func (r *CachedBookRepository) evictStale(ctx context.Context, id uuid.UUID, err error) {
+	if !errors.Is(err, ErrStaleVersion) && !errors.Is(err, ErrNotFound) {
+		return
+	}
+
+	redisErr := r.redisClient.Delete(ctx, getBookKey(id))
+	if redisErr != nil {
+		fmt.Printf("Error evicting stale book from cache: %v\n", redisErr)
+	}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/cache"
+	"context"
+	"github.com/google/uuid"
+)
+
+// CachedRoleRepository leaves reads to the database and only evicts the
+// cached user after role changes, since they can flip its admin flag.
+type CachedRoleRepository struct {
+	IRoleRepository
+	redisClient cache.IRedisClient
+}
+
+func CachedRoleRepo(repo IRoleRepository, redisClient cache.IRedisClient) IRoleRepository {
+	return &CachedRoleRepository{
+		IRoleRepository: repo,
+		redisClient:     redisClient,
+	}
+}
+
+func (r *CachedRoleRepository) AssignToUser(ctx context.Context, userID, roleID uuid.UUID, grantedBy *uuid.UUID) error {
+	defer r.evictUser(ctx, userID)
+	return r.IRoleRepository.AssignToUser(ctx, userID, roleID, grantedBy)
+}
+
+func (r *CachedRoleRepository) RemoveFromUser(ctx context.Context, userID, roleID uuid.UUID) error {
+	defer r.evictUser(ctx, userID)
+	return r.IRoleRepository.RemoveFromUser(ctx, userID, roleID)
+}
+
+func (r *CachedRoleRepository) AssignFromProvider(ctx context.Context, userID, roleID uuid.UUID, provider string) (bool, error) {
+	defer r.evictUser(ctx, userID)
+	return r.IRoleRepository.AssignFromProvider(ctx, userID, roleID, provider)
+}
+
+func (r *CachedRoleRepository) RemoveFromProvider(ctx context.Context, userID, roleID uuid.UUID, provider string) (bool, error) {
+	defer r.evictUser(ctx, userID)
+	return r.IRoleRepository.RemoveFromProvider(ctx, userID, roleID, provider)
+}
+
+func (r *CachedRoleRepository) evictUser(ctx context.Context, userID uuid.UUID) {
+	r.redisClient.Delete(ctx, getUserKey(userID), userListKey)
+}
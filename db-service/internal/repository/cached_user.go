@@ -5,6 +5,7 @@ import (
 	"awesomeProject22/db-service/internal/domain"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
@@ -43,13 +44,30 @@ func getEmailKey(email string) string {
 	return fmt.Sprintf("%s%s", userByEmailPrefix, email)
 }
 
+// marshalCachedUser serializes a copy of the user without its password hash so
+// credentials never end up in Redis.
+func marshalCachedUser(user *domain.User) ([]byte, error) {
+	cached := *user
+	cached.PasswordHash = ""
+	return json.Marshal(&cached)
+}
+
+func marshalCachedUsers(users []domain.User) ([]byte, error) {
+	cached := make([]domain.User, len(users))
+	for i, user := range users {
+		cached[i] = user
+		cached[i].PasswordHash = ""
+	}
+	return json.Marshal(cached)
+}
+
 func (r *CachedUserRepository) Create(ctx context.Context, user *domain.User) error {
 	err := r.repo.Create(ctx, user)
 	if err != nil {
 		return fmt.Errorf("error creating user in database: %w", err)
 	}
 
-	userJson, err := json.Marshal(user)
+	userJson, err := marshalCachedUser(user)
 	if err != nil {
 		return fmt.Errorf("error serializing user: %w", err)
 	}
@@ -94,7 +112,7 @@ func (r *CachedUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*doma
 		return nil, fmt.Errorf("error getting user from database: %w", err)
 	}
 
-	userJson, err := json.Marshal(user)
+	userJson, err := marshalCachedUser(user)
 	if err == nil {
 		r.redisClient.Set(ctx, userKey, string(userJson), userCacheTTL)
 		r.redisClient.Set(ctx, getUsernameKey(user.Username), user.ID.String(), userCacheTTL)
@@ -122,7 +140,7 @@ func (r *CachedUserRepository) GetByUsername(ctx context.Context, username strin
 		return nil, fmt.Errorf("error getting user by username from database: %w", err)
 	}
 
-	userJson, err := json.Marshal(user)
+	userJson, err := marshalCachedUser(user)
 	if err == nil {
 		r.redisClient.Set(ctx, getUserKey(user.ID), string(userJson), userCacheTTL)
 		r.redisClient.Set(ctx, usernameKey, user.ID.String(), userCacheTTL)
@@ -150,7 +168,7 @@ func (r *CachedUserRepository) GetByEmail(ctx context.Context, email string) (*d
 		return nil, fmt.Errorf("error getting user by email from database: %w", err)
 	}
 
-	userJson, err := json.Marshal(user)
+	userJson, err := marshalCachedUser(user)
 	if err == nil {
 		r.redisClient.Set(ctx, getUserKey(user.ID), string(userJson), userCacheTTL)
 		r.redisClient.Set(ctx, getUsernameKey(user.Username), user.ID.String(), userCacheTTL)
@@ -160,7 +178,29 @@ func (r *CachedUserRepository) GetByEmail(ctx context.Context, email string) (*d
 	return user, nil
 }
 
+func (r *CachedUserRepository) GetCredentials(ctx context.Context, username string) (*domain.User, error) {
+	user, err := r.repo.GetCredentials(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user credentials from database: %w", err)
+	}
+	return user, nil
+}
+
 func (r *CachedUserRepository) Update(ctx context.Context, user *domain.User) error {
+	return r.update(ctx, user, func() error {
+		return r.repo.Update(ctx, user)
+	})
+}
+
+func (r *CachedUserRepository) UpdateWithPassword(ctx context.Context, user *domain.User, passwordHash string) error {
+	return r.update(ctx, user, func() error {
+		return r.repo.UpdateWithPassword(ctx, user, passwordHash)
+	})
+}
+
+// update runs a write of the user and refreshes the cached copies, dropping
+// the lookups by a changed username or email.
+func (r *CachedUserRepository) update(ctx context.Context, user *domain.User, write func() error) error {
 	oldUser, err := r.repo.GetByID(ctx, user.ID)
 	if err == nil {
 		if oldUser.Username != user.Username {
@@ -171,12 +211,13 @@ func (r *CachedUserRepository) Update(ctx context.Context, user *domain.User) er
 		}
 	}
 
-	err = r.repo.Update(ctx, user)
+	err = write()
 	if err != nil {
+		r.evictStale(ctx, user.ID, err)
 		return fmt.Errorf("error updating user in database: %w", err)
 	}
 
-	userJson, err := json.Marshal(user)
+	userJson, err := marshalCachedUser(user)
 	if err == nil {
 		r.redisClient.Set(ctx, getUserKey(user.ID), string(userJson), userCacheTTL)
 		r.redisClient.Set(ctx, getUsernameKey(user.Username), user.ID.String(), userCacheTTL)
@@ -188,15 +229,57 @@ func (r *CachedUserRepository) Update(ctx context.Context, user *domain.User) er
 	return nil
 }
 
-func (r *CachedUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *CachedUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	err := r.repo.UpdatePassword(ctx, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("error updating user password in database: %w", err)
+	}
+
+	r.redisClient.Delete(ctx, getUserKey(id))
+
+	return nil
+}
+
+func (r *CachedUserRepository) VerifyEmail(ctx context.Context, id uuid.UUID, email string) error {
+	err := r.repo.VerifyEmail(ctx, id, email)
+	if err != nil {
+		return fmt.Errorf("error updating user email verification in database: %w", err)
+	}
+
+	r.redisClient.Delete(ctx, getUserKey(id))
+	r.redisClient.Delete(ctx, userListKey)
+
+	return nil
+}
+
+func (r *CachedUserRepository) Anonymize(ctx context.Context, id uuid.UUID) error {
+	user, err := r.repo.GetByID(ctx, id)
+	if err == nil {
+		r.redisClient.Delete(ctx, getUsernameKey(user.Username))
+		r.redisClient.Delete(ctx, getEmailKey(user.Email))
+	}
+
+	err = r.repo.Anonymize(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error anonymizing user in database: %w", err)
+	}
+
+	r.redisClient.Delete(ctx, getUserKey(id))
+	r.redisClient.Delete(ctx, userListKey)
+
+	return nil
+}
+
+func (r *CachedUserRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
 	user, err := r.repo.GetByID(ctx, id)
 	if err == nil {
 		r.redisClient.Delete(ctx, getUsernameKey(user.Username))
 		r.redisClient.Delete(ctx, getEmailKey(user.Email))
 	}
 
-	err = r.repo.Delete(ctx, id)
+	err = r.repo.Delete(ctx, id, version)
 	if err != nil {
+		r.evictStale(ctx, id, err)
 		return fmt.Errorf("error deleting user from database: %w", err)
 	}
 
@@ -225,10 +308,69 @@ func (r *CachedUserRepository) GetAll(ctx context.Context) ([]domain.User, error
 		return nil, fmt.Errorf("error getting list of users from database: %w", err)
 	}
 
-	usersJson, err := json.Marshal(users)
+	usersJson, err := marshalCachedUsers(users)
 	if err == nil {
 		r.redisClient.Set(ctx, userListKey, string(usersJson), userCacheTTL)
 	}
 
 	return users, nil
 }
+
+// GetPage reads pages from the database; caching every limit and offset a
+// client asks for would hardly ever hit.
+func (r *CachedUserRepository) GetPage(ctx context.Context, limit, offset int) ([]domain.User, int, error) {
+	return r.repo.GetPage(ctx, limit, offset)
+}
+
+// GetByIDs and GetLoans serve the batched reads of the GraphQL API, which
+// already load many records per query, from the database.
+func (r *CachedUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	return r.repo.GetByIDs(ctx, ids)
+}
+
+func (r *CachedUserRepository) GetLoans(ctx context.Context, userIDs []uuid.UUID) ([]domain.UserBook, error) {
+	return r.repo.GetLoans(ctx, userIDs)
+}
+
+// ApplyBatch invalidates the cache once the batch has committed, including the
+// lookups by the old and new username and email.
+func (r *CachedUserRepository) ApplyBatch(ctx context.Context, items []domain.UserBatchItem) error {
+	if err := r.repo.ApplyBatch(ctx, items); err != nil {
+		return fmt.Errorf("error applying user batch in database: %w", err)
+	}
+
+	keys := map[string]bool{userListKey: true}
+	for _, item := range items {
+		for _, user := range []*domain.User{item.User, item.Current} {
+			if user == nil {
+				continue
+			}
+			keys[getUserKey(user.ID)] = true
+			if user.Username != "" {
+				keys[getUsernameKey(user.Username)] = true
+			}
+			if user.Email != "" {
+				keys[getEmailKey(user.Email)] = true
+			}
+		}
+	}
+
+	staleKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		staleKeys = append(staleKeys, key)
+	}
+	r.redisClient.Delete(ctx, staleKeys...)
+
+	return nil
+}
+
+// evictStale drops the cached copies when a conditional write shows they are
+// out of date.
+func (r *CachedUserRepository) evictStale(ctx context.Context, id uuid.UUID, err error) {
+	if !errors.Is(err, ErrStaleVersion) && !errors.Is(err, ErrNotFound) {
+		return
+	}
+
+	r.redisClient.Delete(ctx, getUserKey(id))
+	r.redisClient.Delete(ctx, userListKey)
+}
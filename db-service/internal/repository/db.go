@@ -1,12 +1,72 @@
 package repository
 
 import (
+	"awesomeProject22/db-service/internal/domain"
 	"context"
 	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// querier runs statements on the pool or inside a transaction, so a write can
+// be shared by single requests and batches.
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// inTransaction runs fn in a transaction that commits only when fn succeeds.
+func inTransaction(ctx context.Context, db *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// uuidStrings prepares ids for a uuid[] parameter.
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
+
+func queryLoans(ctx context.Context, db *pgxpool.Pool, query string, ids []string) ([]domain.UserBook, error) {
+	rows, err := db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting loans: %w", err)
+	}
+	defer rows.Close()
+
+	loans := []domain.UserBook{}
+	for rows.Next() {
+		var loan domain.UserBook
+		if err := rows.Scan(&loan.UserID, &loan.BookID); err != nil {
+			return nil, fmt.Errorf("error scanning loan: %w", err)
+		}
+		loans = append(loans, loan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing loans: %w", err)
+	}
+
+	return loans, nil
+}
+
 type Config struct {
 	Host     string
 	Port     string
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrStaleVersion = errors.New("stale version")
+)
+
+// AnyVersion skips the version check of conditional updates and deletes.
+const AnyVersion int64 = 0
+
+const pgUniqueViolation = "23505"
+
+// ConflictError reports a write rejected by a unique constraint. It matches
+// ErrConflict and keeps the constraint name so services can tell which value
+// was taken.
+type ConflictError struct {
+	Constraint string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: unique constraint %s violated", ErrConflict, e.Constraint)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// BatchItemError names the item that failed a batch. Nothing of the batch was
+// written.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// staleOrMissing explains why a conditional write matched no row: either the
+// row is gone or somebody else changed it first.
+func staleOrMissing(ctx context.Context, db querier, existsQuery, entity string, id uuid.UUID) error {
+	var exists bool
+	if err := db.QueryRow(ctx, existsQuery, id).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking %s with ID %s: %w", entity, id, err)
+	}
+	if exists {
+		return fmt.Errorf("%s with ID %s has a %w", entity, id, ErrStaleVersion)
+	}
+	return fmt.Errorf("%s with ID %s %w", entity, id, ErrNotFound)
+}
+
+// mapWriteError turns driver errors callers can act on into repository
+// errors and leaves everything else untouched.
+func mapWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return &ConflictError{Constraint: pgErr.ConstraintName}
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type IdentityRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewIdentityRepository(db *pgxpool.Pool) IIdentityRepository {
+	return &IdentityRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *IdentityRepositoryImpl) Get(ctx context.Context, provider, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	query := `SELECT provider, subject, user_id, email, created_at, last_login_at
+              FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email,
+		&identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("identity %s/%s %w", provider, subject, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error requesting identity %s/%s: %w", provider, subject, err)
+	}
+
+	return &identity, nil
+}
+
+func (r *IdentityRepositoryImpl) Link(ctx context.Context, identity *domain.UserIdentity) error {
+	query := `INSERT INTO user_identities (provider, subject, user_id, email, last_login_at)
+              VALUES ($1, $2, $3, $4, NOW())
+              RETURNING created_at, last_login_at`
+	err := r.db.QueryRow(ctx, query,
+		identity.Provider, identity.Subject, identity.UserID, identity.Email).Scan(&identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		return fmt.Errorf("error linking identity %s/%s: %w", identity.Provider, identity.Subject, mapWriteError(err))
+	}
+	return nil
+}
+
+func (r *IdentityRepositoryImpl) TouchLogin(ctx context.Context, provider, subject, email string) error {
+	query := `UPDATE user_identities SET last_login_at = NOW(), email = $1 WHERE provider = $2 AND subject = $3`
+	_, err := r.db.Exec(ctx, query, email, provider, subject)
+	if err != nil {
+		return fmt.Errorf("error updating identity %s/%s: %w", provider, subject, err)
+	}
+	return nil
+}
@@ -4,22 +4,105 @@ import (
 	"awesomeProject22/db-service/internal/domain"
 	"context"
 	"github.com/google/uuid"
+	"time"
 )
 
 type IUserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error)
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetCredentials(ctx context.Context, username string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	UpdateWithPassword(ctx context.Context, user *domain.User, passwordHash string) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	VerifyEmail(ctx context.Context, id uuid.UUID, email string) error
+	Anonymize(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
 	GetAll(ctx context.Context) ([]domain.User, error)
+	GetPage(ctx context.Context, limit, offset int) ([]domain.User, int, error)
+	GetLoans(ctx context.Context, userIDs []uuid.UUID) ([]domain.UserBook, error)
+	ApplyBatch(ctx context.Context, items []domain.UserBatchItem) error
 }
 
 type IBookRepository interface {
 	Create(ctx context.Context, book *domain.Book) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Book, error)
 	GetAll(ctx context.Context, author, genre string) ([]*domain.Book, error)
+	GetPage(ctx context.Context, author, genre string, limit, offset int) ([]*domain.Book, int, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Book, error)
+	GetByAuthors(ctx context.Context, authors []string) ([]*domain.Book, error)
+	GetLoans(ctx context.Context, bookIDs []uuid.UUID) ([]domain.UserBook, error)
 	Update(ctx context.Context, book *domain.Book) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	ApplyBatch(ctx context.Context, items []domain.BookBatchItem) error
+	LastModified(ctx context.Context) (time.Time, error)
+}
+
+type IRoleRepository interface {
+	GetAll(ctx context.Context) ([]domain.Role, error)
+	GetByName(ctx context.Context, name string) (*domain.Role, error)
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error)
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	AssignToUser(ctx context.Context, userID, roleID uuid.UUID, grantedBy *uuid.UUID) error
+	RemoveFromUser(ctx context.Context, userID, roleID uuid.UUID) error
+	GetProviderRoles(ctx context.Context, userID uuid.UUID, provider string) ([]domain.Role, error)
+	AssignFromProvider(ctx context.Context, userID, roleID uuid.UUID, provider string) (bool, error)
+	RemoveFromProvider(ctx context.Context, userID, roleID uuid.UUID, provider string) (bool, error)
+}
+
+type ISecurityAuditRepository interface {
+	Record(ctx context.Context, event *domain.SecurityEvent) error
+}
+
+type IUserTokenRepository interface {
+	Create(ctx context.Context, token *domain.UserToken) error
+	Consume(ctx context.Context, tokenHash, purpose string) (*domain.UserToken, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error
+}
+
+type ITwoFactorRepository interface {
+	GetByUser(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error)
+	SavePending(ctx context.Context, userID uuid.UUID, secret string) error
+	Enable(ctx context.Context, userID uuid.UUID, step int64) error
+	MarkStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+}
+
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	GetAll(ctx context.Context) ([]domain.APIKey, error)
+	Rotate(ctx context.Context, id uuid.UUID, prefix, keyHash string) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+type IIdentityRepository interface {
+	Get(ctx context.Context, provider, subject string) (*domain.UserIdentity, error)
+	Link(ctx context.Context, identity *domain.UserIdentity) error
+	TouchLogin(ctx context.Context, provider, subject, email string) error
+}
+
+type IPrivacyRepository interface {
+	GetLoans(ctx context.Context, userID uuid.UUID) ([]domain.Book, error)
+	GetSecurityEvents(ctx context.Context, userID uuid.UUID) ([]domain.SecurityEvent, error)
+	GetIdentities(ctx context.Context, userID uuid.UUID) ([]domain.UserIdentity, error)
+}
+
+type IAuditRepository interface {
+	Append(ctx context.Context, entry *domain.AuditEntry, chain bool) error
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	Verify(ctx context.Context) (*domain.AuditVerification, error)
+}
+
+type ILoginHistoryRepository interface {
+	Record(ctx context.Context, record *domain.LoginRecord) error
+	GetHistory(ctx context.Context, userID uuid.UUID, limit int) ([]domain.LoginRecord, error)
+	GetDevices(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error)
+	HasSeenNetwork(ctx context.Context, userID uuid.UUID, network string) (bool, error)
 }
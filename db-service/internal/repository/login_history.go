@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type LoginHistoryRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginHistoryRepository(db *pgxpool.Pool) ILoginHistoryRepository {
+	return &LoginHistoryRepositoryImpl{
+		db: db,
+	}
+}
+
+// Record stores the login and refreshes the matching known device in one
+// transaction so the history and the device list never disagree.
+func (r *LoginHistoryRepositoryImpl) Record(ctx context.Context, record *domain.LoginRecord) error {
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	if record.Reasons == nil {
+		record.Reasons = []string{}
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting login record transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO login_history (id, user_id, ip, network, user_agent, fingerprint, suspicious, reasons, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err = tx.Exec(ctx, query, record.ID, record.UserID, record.IP, record.Network, record.UserAgent,
+		record.Fingerprint, record.Suspicious, record.Reasons, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error recording login of user %s: %w", record.UserID, err)
+	}
+
+	query = `INSERT INTO user_devices (user_id, fingerprint, user_agent, last_ip, first_seen_at, last_seen_at)
+             VALUES ($1, $2, $3, $4, $5, $5)
+             ON CONFLICT (user_id, fingerprint) DO UPDATE
+             SET user_agent = EXCLUDED.user_agent, last_ip = EXCLUDED.last_ip, last_seen_at = EXCLUDED.last_seen_at`
+	_, err = tx.Exec(ctx, query, record.UserID, record.Fingerprint, record.UserAgent, record.IP, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error updating known device of user %s: %w", record.UserID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing login record: %w", err)
+	}
+	return nil
+}
+
+// GetHistory returns the newest logins first; a limit of zero returns all.
+func (r *LoginHistoryRepositoryImpl) GetHistory(ctx context.Context, userID uuid.UUID, limit int) ([]domain.LoginRecord, error) {
+	query := `SELECT id, user_id, ip, network, user_agent, fingerprint, suspicious, reasons, created_at
+              FROM login_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	rows, err := r.db.Query(ctx, query, userID, limitArg)
+	if err != nil {
+		return nil, fmt.Errorf("error getting login history of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	records := []domain.LoginRecord{}
+	for rows.Next() {
+		var record domain.LoginRecord
+		err := rows.Scan(&record.ID, &record.UserID, &record.IP, &record.Network, &record.UserAgent,
+			&record.Fingerprint, &record.Suspicious, &record.Reasons, &record.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning login record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing login history: %w", err)
+	}
+
+	return records, nil
+}
+
+func (r *LoginHistoryRepositoryImpl) GetDevices(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error) {
+	query := `SELECT user_id, fingerprint, user_agent, last_ip, first_seen_at, last_seen_at
+              FROM user_devices WHERE user_id = $1 ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting devices of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	devices := []domain.KnownDevice{}
+	for rows.Next() {
+		var device domain.KnownDevice
+		err := rows.Scan(&device.UserID, &device.Fingerprint, &device.UserAgent, &device.LastIP,
+			&device.FirstSeenAt, &device.LastSeenAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (r *LoginHistoryRepositoryImpl) HasSeenNetwork(ctx context.Context, userID uuid.UUID, network string) (bool, error) {
+	var seen bool
+	query := `SELECT EXISTS (SELECT 1 FROM login_history WHERE user_id = $1 AND network = $2)`
+	if err := r.db.QueryRow(ctx, query, userID, network).Scan(&seen); err != nil {
+		return false, fmt.Errorf("error checking known networks of user %s: %w", userID, err)
+	}
+	return seen, nil
+}
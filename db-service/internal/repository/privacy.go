@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PrivacyRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewPrivacyRepository(db *pgxpool.Pool) IPrivacyRepository {
+	return &PrivacyRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *PrivacyRepositoryImpl) GetLoans(ctx context.Context, userID uuid.UUID) ([]domain.Book, error) {
+	query := `SELECT b.id, b.genre, b.name, b.author, b.year, b.version, b.updated_at
+              FROM user_book ub JOIN books b ON b.id = ub.book_id
+              WHERE ub.user_id = $1 ORDER BY b.name`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting loans of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	books := []domain.Book{}
+	for rows.Next() {
+		var book domain.Book
+		if err := rows.Scan(&book.ID, &book.Genre, &book.Name, &book.Author, &book.Year, &book.Version, &book.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning loan: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing loans: %w", err)
+	}
+
+	return books, nil
+}
+
+func (r *PrivacyRepositoryImpl) GetSecurityEvents(ctx context.Context, userID uuid.UUID) ([]domain.SecurityEvent, error) {
+	query := `SELECT id, event_type, actor_id, target_user_id, details, created_at
+              FROM security_audit_log WHERE target_user_id = $1 OR actor_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting security events of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	events := []domain.SecurityEvent{}
+	for rows.Next() {
+		var event domain.SecurityEvent
+		var details []byte
+		if err := rows.Scan(&event.ID, &event.EventType, &event.ActorID, &event.TargetUserID, &details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning security event: %w", err)
+		}
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, fmt.Errorf("error deserializing security event details: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing security events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *PrivacyRepositoryImpl) GetIdentities(ctx context.Context, userID uuid.UUID) ([]domain.UserIdentity, error) {
+	query := `SELECT provider, subject, user_id, email, created_at, last_login_at
+              FROM user_identities WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting identities of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	identities := []domain.UserIdentity{}
+	for rows.Next() {
+		var identity domain.UserIdentity
+		if err := rows.Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email,
+			&identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			return nil, fmt.Errorf("error scanning identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing identities: %w", err)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type RoleRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRoleRepository(db *pgxpool.Pool) IRoleRepository {
+	return &RoleRepositoryImpl{
+		db: db,
+	}
+}
+
+const roleSelect = `SELECT r.id, r.name, r.description,
+                           COALESCE(array_agg(rp.permission_code) FILTER (WHERE rp.permission_code IS NOT NULL), '{}')
+                    FROM roles r
+                    LEFT JOIN role_permissions rp ON rp.role_id = r.id`
+
+func (r *RoleRepositoryImpl) GetAll(ctx context.Context) ([]domain.Role, error) {
+	query := roleSelect + ` GROUP BY r.id ORDER BY r.name`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting list of roles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func (r *RoleRepositoryImpl) GetByName(ctx context.Context, name string) (*domain.Role, error) {
+	var role domain.Role
+	query := roleSelect + ` WHERE r.name = $1 GROUP BY r.id`
+
+	err := r.db.QueryRow(ctx, query, name).Scan(
+		&role.ID, &role.Name, &role.Description, &role.Permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("role %s %w", name, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error requesting role %s: %w", name, err)
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepositoryImpl) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
+	query := roleSelect + `
+              JOIN user_roles ur ON ur.role_id = r.id
+              WHERE ur.user_id = $1
+              GROUP BY r.id ORDER BY r.name`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func (r *RoleRepositoryImpl) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	query := `SELECT DISTINCT rp.permission_code
+              FROM user_roles ur
+              JOIN role_permissions rp ON rp.role_id = ur.role_id
+              WHERE ur.user_id = $1`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting permissions of user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("error scanning permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error when processing results: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// AssignToUser grants a role directly. A role the user already holds through
+// an identity provider becomes a direct grant, so the provider no longer
+// revokes it.
+func (r *RoleRepositoryImpl) AssignToUser(ctx context.Context, userID, roleID uuid.UUID, grantedBy *uuid.UUID) error {
+	query := `INSERT INTO user_roles (user_id, role_id, granted_by) VALUES ($1, $2, $3)
+              ON CONFLICT (user_id, role_id) DO UPDATE SET granted_via = NULL`
+	err := inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query, userID, roleID, grantedBy); err != nil {
+			return err
+		}
+		return syncAdminFlag(ctx, tx, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("error assigning role %s to user %s: %w", roleID, userID, err)
+	}
+	return nil
+}
+
+func (r *RoleRepositoryImpl) RemoveFromUser(ctx context.Context, userID, roleID uuid.UUID) error {
+	query := `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`
+	err := inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query, userID, roleID); err != nil {
+			return err
+		}
+		return syncAdminFlag(ctx, tx, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("error removing role %s from user %s: %w", roleID, userID, err)
+	}
+	return nil
+}
+
+// GetProviderRoles lists the roles the claim mapping of a provider granted.
+func (r *RoleRepositoryImpl) GetProviderRoles(ctx context.Context, userID uuid.UUID, provider string) ([]domain.Role, error) {
+	query := roleSelect + `
+              JOIN user_roles ur ON ur.role_id = r.id
+              WHERE ur.user_id = $1 AND ur.granted_via = $2
+              GROUP BY r.id ORDER BY r.name`
+
+	rows, err := r.db.Query(ctx, query, userID, provider)
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles of user %s granted by %s: %w", userID, provider, err)
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// AssignFromProvider grants a role on behalf of an identity provider and
+// reports whether the user did not hold it before.
+func (r *RoleRepositoryImpl) AssignFromProvider(ctx context.Context, userID, roleID uuid.UUID, provider string) (bool, error) {
+	query := `INSERT INTO user_roles (user_id, role_id, granted_via) VALUES ($1, $2, $3)
+              ON CONFLICT (user_id, role_id) DO NOTHING`
+	return r.changeRoles(ctx, userID, query, roleID, provider)
+}
+
+// changeRoles runs a statement on the roles of a user, refreshes the admin
+// flag in the same transaction and reports whether a row changed.
+func (r *RoleRepositoryImpl) changeRoles(ctx context.Context, userID uuid.UUID, query string, roleID uuid.UUID, provider string) (bool, error) {
+	var changed bool
+	err := inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query, userID, roleID, provider)
+		if err != nil {
+			return err
+		}
+		changed = tag.RowsAffected() > 0
+		return syncAdminFlag(ctx, tx, userID)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error changing role %s of user %s: %w", roleID, userID, err)
+	}
+	return changed, nil
+}
+
+// RemoveFromProvider revokes a role only while it is still held through the
+// provider, leaving direct grants alone.
+func (r *RoleRepositoryImpl) RemoveFromProvider(ctx context.Context, userID, roleID uuid.UUID, provider string) (bool, error) {
+	query := `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2 AND granted_via = $3`
+	return r.changeRoles(ctx, userID, query, roleID, provider)
+}
+
+// syncAdminFlag mirrors the admin role into the legacy is_admin column and
+// bumps the user version when the flag changes, so cached copies and ETags of
+// the user go stale.
+func syncAdminFlag(ctx context.Context, db querier, userID uuid.UUID) error {
+	query := `UPDATE users u SET is_admin = a.held, version = u.version + 1
+              FROM (SELECT EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+                                   WHERE ur.user_id = $1 AND r.name = $2) AS held) a
+              WHERE u.id = $1 AND u.is_admin <> a.held`
+	if _, err := db.Exec(ctx, query, userID, domain.RoleAdmin); err != nil {
+		return fmt.Errorf("error updating admin flag of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func scanRoles(rows pgx.Rows) ([]domain.Role, error) {
+	roles := []domain.Role{}
+
+	for rows.Next() {
+		var role domain.Role
+		err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.Permissions)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning role data: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing results: %w", err)
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type SecurityAuditRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewSecurityAuditRepository(db *pgxpool.Pool) ISecurityAuditRepository {
+	return &SecurityAuditRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *SecurityAuditRepositoryImpl) Record(ctx context.Context, event *domain.SecurityEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if event.Details == nil {
+		event.Details = map[string]interface{}{}
+	}
+
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("error serializing security event details: %w", err)
+	}
+
+	query := `INSERT INTO security_audit_log (id, event_type, actor_id, target_user_id, details, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = r.db.Exec(ctx, query,
+		event.ID, event.EventType, event.ActorID, event.TargetUserID, details, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error recording security event: %w", err)
+	}
+	return nil
+}
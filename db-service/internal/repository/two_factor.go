@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TwoFactorRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewTwoFactorRepository(db *pgxpool.Pool) ITwoFactorRepository {
+	return &TwoFactorRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *TwoFactorRepositoryImpl) GetByUser(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	var twoFactor domain.TwoFactor
+	query := `SELECT user_id, secret, enabled, last_used_step, created_at, enabled_at FROM user_totp WHERE user_id = $1`
+
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&twoFactor.UserID, &twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt, &twoFactor.EnabledAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("two-factor settings of user %s %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error requesting two-factor settings of user %s: %w", userID, err)
+	}
+
+	return &twoFactor, nil
+}
+
+// SavePending replaces any previous enrollment; the new secret stays disabled
+// until Enable is called with a verified code.
+func (r *TwoFactorRepositoryImpl) SavePending(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+              ON CONFLICT (user_id) DO UPDATE
+              SET secret = EXCLUDED.secret, enabled = FALSE, last_used_step = 0, created_at = NOW(), enabled_at = NULL`
+	_, err := r.db.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("error saving two-factor secret of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepositoryImpl) Enable(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `UPDATE user_totp SET enabled = TRUE, enabled_at = NOW(), last_used_step = $1 WHERE user_id = $2`
+	_, err := r.db.Exec(ctx, query, step, userID)
+	if err != nil {
+		return fmt.Errorf("error enabling two-factor for user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// MarkStepUsed only advances the stored step, so a code that was already
+// accepted cannot be replayed within its validity window.
+func (r *TwoFactorRepositoryImpl) MarkStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1`
+	tag, err := r.db.Exec(ctx, query, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("error updating two-factor step of user %s: %w", userID, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *TwoFactorRepositoryImpl) Delete(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting recovery codes of user %s: %w", userID, err)
+	}
+
+	_, err = r.db.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting two-factor settings of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes of user %s: %w", userID, err)
+	}
+
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			return fmt.Errorf("error storing recovery code of user %s: %w", userID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing recovery codes of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepositoryImpl) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `UPDATE user_recovery_codes SET used_at = NOW()
+              WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("error consuming recovery code of user %s: %w", userID, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -21,29 +21,35 @@ func UserRepo(db *pgxpool.Pool) IUserRepository {
 }
 
 func (r *UserRepositoryImpl) Create(ctx context.Context, user *domain.User) error {
+	return inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		return createUser(ctx, tx, user)
+	})
+}
+
+func createUser(ctx context.Context, db querier, user *domain.User) error {
 	if user.ID == uuid.Nil {
 		user.ID = uuid.New()
 	}
 
-	query := `INSERT INTO users (id, username, email, password_hash, is_admin) 
-              VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(ctx, query,
-		user.ID, user.Username, user.Email, user.PasswordHash, user.IsAdmin)
+	query := `INSERT INTO users (id, username, email, password_hash, is_admin, email_verified) 
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING version`
+	err := db.QueryRow(ctx, query,
+		user.ID, user.Username, user.Email, user.PasswordHash, user.IsAdmin, user.EmailVerified).Scan(&user.Version)
 	if err != nil {
-		return fmt.Errorf("error creating user: %w", err)
+		return fmt.Errorf("error creating user: %w", mapWriteError(err))
 	}
-	return nil
+	return syncAdminRole(ctx, db, user.ID, user.IsAdmin)
 }
 
 func (r *UserRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	var user domain.User
-	query := `SELECT id, username, email, is_admin FROM users WHERE id = $1`
+	query := `SELECT id, username, email, is_admin, email_verified, version, erased_at FROM users WHERE id = $1`
 
 	err := r.db.QueryRow(ctx, query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.IsAdmin)
+		&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.EmailVerified, &user.Version, &user.ErasedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("user with ID %s not found", id)
+			return nil, fmt.Errorf("user with ID %s %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("error requesting user with ID %s: %w", id, err)
 	}
@@ -51,15 +57,68 @@ func (r *UserRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	return &user, nil
 }
 
+// GetByIDs returns the users among ids that exist, in no particular order.
+func (r *UserRepositoryImpl) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	query := `SELECT id, username, email, is_admin, email_verified, version, erased_at FROM users WHERE id = ANY($1::uuid[])`
+	return r.queryUsers(ctx, query, uuidStrings(ids))
+}
+
+// GetPage returns one page of users ordered by username and the number of all
+// users.
+func (r *UserRepositoryImpl) GetPage(ctx context.Context, limit, offset int) ([]domain.User, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error counting users: %w", err)
+	}
+
+	query := `SELECT id, username, email, is_admin, email_verified, version, erased_at FROM users
+              ORDER BY username, id LIMIT $1 OFFSET $2`
+	users, err := r.queryUsers(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *UserRepositoryImpl) queryUsers(ctx context.Context, query string, args ...interface{}) ([]domain.User, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		var user domain.User
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.EmailVerified, &user.Version, &user.ErasedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user data: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after processing results: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetLoans returns the loans of the given users.
+func (r *UserRepositoryImpl) GetLoans(ctx context.Context, userIDs []uuid.UUID) ([]domain.UserBook, error) {
+	query := `SELECT user_id, book_id FROM user_book WHERE user_id = ANY($1::uuid[])`
+	return queryLoans(ctx, r.db, query, uuidStrings(userIDs))
+}
+
 func (r *UserRepositoryImpl) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	var user domain.User
-	query := `SELECT id, username, email, password_hash, is_admin FROM users WHERE username = $1`
+	query := `SELECT id, username, email, is_admin, email_verified, version, erased_at FROM users WHERE username = $1`
 
 	err := r.db.QueryRow(ctx, query, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.IsAdmin)
+		&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.EmailVerified, &user.Version, &user.ErasedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("username %s not found", username)
+			return nil, fmt.Errorf("username %s %w", username, ErrNotFound)
 		}
 		return nil, fmt.Errorf("error requesting user with name %s: %w", username, err)
 	}
@@ -67,16 +126,34 @@ func (r *UserRepositoryImpl) GetByUsername(ctx context.Context, username string)
 	return &user, nil
 }
 
+// GetCredentials is the only query that reads password_hash. Callers must not
+// cache its result.
+func (r *UserRepositoryImpl) GetCredentials(ctx context.Context, username string) (*domain.User, error) {
+	var user domain.User
+	query := `SELECT id, username, email, password_hash, is_admin, email_verified, version, erased_at FROM users WHERE username = $1`
+
+	err := r.db.QueryRow(ctx, query, username).Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.IsAdmin, &user.EmailVerified, &user.Version, &user.ErasedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("username %s %w", username, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error requesting credentials of user %s: %w", username, err)
+	}
+
+	return &user, nil
+}
+
 func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
 
-	query := `SELECT id, username, email, is_admin FROM users WHERE email = $1`
+	query := `SELECT id, username, email, is_admin, email_verified, version, erased_at FROM users WHERE email = $1`
 
 	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.IsAdmin)
+		&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.EmailVerified, &user.Version, &user.ErasedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, fmt.Errorf("user with email %s %w", email, ErrNotFound)
 		}
 		return nil, fmt.Errorf("error when requesting user with email %s: %w", email, err)
 	}
@@ -84,27 +161,190 @@ func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*dom
 	return &user, nil
 }
 
+// Update only succeeds while the stored version still equals user.Version, or
+// unconditionally for AnyVersion, and stores the incremented version in user.
 func (r *UserRepositoryImpl) Update(ctx context.Context, user *domain.User) error {
-	query := `UPDATE users SET username = $1, email = $2, password_hash = $3, is_admin = $4 WHERE id = $5`
-	_, err := r.db.Exec(ctx, query,
-		user.Username, user.Email, user.PasswordHash, user.IsAdmin, user.ID)
+	return inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		return updateUser(ctx, tx, user)
+	})
+}
+
+func updateUser(ctx context.Context, db querier, user *domain.User) error {
+	query := `UPDATE users SET username = $1, email = $2, is_admin = $3, email_verified = $4, version = version + 1
+              WHERE id = $5 AND ($6 = 0 OR version = $6) RETURNING version`
+	err := db.QueryRow(ctx, query,
+		user.Username, user.Email, user.IsAdmin, user.EmailVerified, user.ID, user.Version).Scan(&user.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return userVersionMismatch(ctx, db, user.ID)
+		}
+		return fmt.Errorf("error updating user with ID %s: %w", user.ID, mapWriteError(err))
+	}
+	return syncAdminRole(ctx, db, user.ID, user.IsAdmin)
+}
+
+// syncAdminRole grants or removes the admin role to match the is_admin flag
+// written with the user. Permissions come from roles only, and the flag is
+// kept as their mirror for clients that still read it.
+func syncAdminRole(ctx context.Context, db querier, userID uuid.UUID, isAdmin bool) error {
+	query := `DELETE FROM user_roles WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)`
+	if isAdmin {
+		query = `INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE name = $2
+                 ON CONFLICT (user_id, role_id) DO NOTHING`
+	}
+	if _, err := db.Exec(ctx, query, userID, domain.RoleAdmin); err != nil {
+		return fmt.Errorf("error updating admin role of user with ID %s: %w", userID, err)
+	}
+	return nil
+}
+
+func userVersionMismatch(ctx context.Context, db querier, id uuid.UUID) error {
+	return staleOrMissing(ctx, db, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, "user", id)
+}
+
+// UpdateWithPassword writes the profile and the new password hash in one
+// transaction, so that neither is stored without the other.
+func (r *UserRepositoryImpl) UpdateWithPassword(ctx context.Context, user *domain.User, passwordHash string) error {
+	return inTransaction(ctx, r.db, func(tx pgx.Tx) error {
+		if err := updateUser(ctx, tx, user); err != nil {
+			return err
+		}
+		return updateUserPassword(ctx, tx, user.ID, passwordHash)
+	})
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return updateUserPassword(ctx, r.db, id, passwordHash)
+}
+
+func updateUserPassword(ctx context.Context, db querier, id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1 WHERE id = $2`
+	_, err := db.Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("error updating password of user with ID %s: %w", id, err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the email address as verified only while the account
+// still has that address.
+func (r *UserRepositoryImpl) VerifyEmail(ctx context.Context, id uuid.UUID, email string) error {
+	query := `UPDATE users SET email_verified = TRUE, version = version + 1 WHERE id = $1 AND email = $2`
+	tag, err := r.db.Exec(ctx, query, id, email)
+	if err != nil {
+		return fmt.Errorf("error updating email verification of user with ID %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user with ID %s and email %s %w", id, email, ErrNotFound)
+	}
+	return nil
+}
+
+// securityEventPersonalDetails are the details of security events that
+// identify the user's network or device.
+var securityEventPersonalDetails = []string{"ip", "network", "user_agent", "fingerprint"}
+
+// Anonymize replaces the personal fields of the account, removes everything
+// that could identify or authenticate the person and revokes the API keys they
+// created. The row itself stays so that loans and audit records keep a valid
+// reference.
+func (r *UserRepositoryImpl) Anonymize(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting erasure of user with ID %s: %w", id, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE users SET username = 'erased-' || id::text, email = 'erased-' || id::text || '@erased.invalid',
+              password_hash = '', is_admin = FALSE, email_verified = FALSE, erased_at = NOW(), version = version + 1
+              WHERE id = $1 AND erased_at IS NULL`
+	tag, err := tx.Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("error updating user with ID %s: %w", user.ID, err)
+		return fmt.Errorf("error anonymizing user with ID %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user with ID %s %w", id, ErrNotFound)
+	}
+
+	// Keys outlive the session of whoever created them, so they have to stop
+	// working explicitly.
+	query = `UPDATE api_keys SET revoked_at = NOW() WHERE created_by = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("error revoking api keys of user with ID %s: %w", id, err)
+	}
+
+	for _, table := range []string{"user_tokens", "user_totp", "user_recovery_codes", "user_identities", "user_roles",
+		"user_devices", "login_history"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("error erasing %s of user with ID %s: %w", table, id, err)
+		}
+	}
+
+	// Security events stay for accountability, without the network and device
+	// details of the user.
+	query = `UPDATE security_audit_log SET details = details - $2::text[] WHERE target_user_id = $1`
+	if _, err := tx.Exec(ctx, query, id, securityEventPersonalDetails); err != nil {
+		return fmt.Errorf("error erasing security events of user with ID %s: %w", id, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing erasure of user with ID %s: %w", id, err)
 	}
 	return nil
 }
 
-func (r *UserRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+func (r *UserRepositoryImpl) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	return deleteUser(ctx, r.db, id, version)
+}
+
+func deleteUser(ctx context.Context, db querier, id uuid.UUID, version int64) error {
+	query := `DELETE FROM users WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	tag, err := db.Exec(ctx, query, id, version)
 	if err != nil {
 		return fmt.Errorf("error deleting user with ID %s: %w", id, err)
 	}
+	if tag.RowsAffected() == 0 {
+		return userVersionMismatch(ctx, db, id)
+	}
+	return nil
+}
+
+// ApplyBatch writes all items in one transaction. The first failing item rolls
+// everything back and is reported as a BatchItemError.
+func (r *UserRepositoryImpl) ApplyBatch(ctx context.Context, items []domain.UserBatchItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting user batch: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i, item := range items {
+		switch item.Op {
+		case domain.BatchCreate:
+			err = createUser(ctx, tx, item.User)
+		case domain.BatchUpdate:
+			err = updateUser(ctx, tx, item.User)
+			if err == nil && item.PasswordChanged {
+				err = updateUserPassword(ctx, tx, item.User.ID, item.User.PasswordHash)
+			}
+		case domain.BatchDelete:
+			err = deleteUser(ctx, tx, item.User.ID, item.User.Version)
+		default:
+			err = fmt.Errorf("unknown batch operation %q", item.Op)
+		}
+		if err != nil {
+			return &BatchItemError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing user batch: %w", err)
+	}
 	return nil
 }
 
 func (r *UserRepositoryImpl) GetAll(ctx context.Context) ([]domain.User, error) {
-	query := `SELECT id, username, email, is_admin FROM users`
+	query := `SELECT id, username, email, is_admin, email_verified, version, erased_at FROM users`
 
 	rows, err := r.db.Query(ctx, query)
 	if err != nil {
@@ -116,7 +356,7 @@ func (r *UserRepositoryImpl) GetAll(ctx context.Context) ([]domain.User, error)
 
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin)
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.EmailVerified, &user.Version, &user.ErasedAt)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning user data: %w", err)
 		}
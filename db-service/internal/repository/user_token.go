@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type UserTokenRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewUserTokenRepository(db *pgxpool.Pool) IUserTokenRepository {
+	return &UserTokenRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *UserTokenRepositoryImpl) Create(ctx context.Context, token *domain.UserToken) error {
+	query := `INSERT INTO user_tokens (token_hash, user_id, purpose, email, expires_at) VALUES ($1, $2, $3, $4, $5)
+              RETURNING created_at`
+	err := r.db.QueryRow(ctx, query,
+		token.TokenHash, token.UserID, token.Purpose, token.Email, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating %s token: %w", token.Purpose, err)
+	}
+	return nil
+}
+
+// Consume marks an unused, unexpired token as used in a single statement so
+// that the same token can never be redeemed twice.
+func (r *UserTokenRepositoryImpl) Consume(ctx context.Context, tokenHash, purpose string) (*domain.UserToken, error) {
+	var token domain.UserToken
+	query := `UPDATE user_tokens SET used_at = NOW()
+              WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+              RETURNING token_hash, user_id, purpose, email, expires_at, used_at, created_at`
+
+	err := r.db.QueryRow(ctx, query, tokenHash, purpose).Scan(
+		&token.TokenHash, &token.UserID, &token.Purpose, &token.Email, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s token %w", purpose, ErrNotFound)
+		}
+		return nil, fmt.Errorf("error consuming %s token: %w", purpose, err)
+	}
+
+	return &token, nil
+}
+
+func (r *UserTokenRepositoryImpl) DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error {
+	query := `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`
+	_, err := r.db.Exec(ctx, query, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("error deleting %s tokens of user %s: %w", purpose, userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package requestctx
+
+import (
+	"context"
+)
+
+type ClientInfo struct {
+	IP             string
+	UserAgent      string
+	AcceptLanguage string
+	DeviceID       string
+	RequestID      string
+}
+
+type clientInfoKey struct{}
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
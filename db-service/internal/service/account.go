@@ -0,0 +1,220 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/mail"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+type AccountConfig struct {
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	BaseURL              string
+}
+
+type AccountServiceImpl struct {
+	userRepo      repository.IUserRepository
+	tokenRepo     repository.IUserTokenRepository
+	securityAudit repository.ISecurityAuditRepository
+	mailSender    mail.IMailSender
+	hasher        auth.IPasswordHasher
+	loginGuard    ILoginGuard
+	config        AccountConfig
+}
+
+func AccountService(userRepo repository.IUserRepository, tokenRepo repository.IUserTokenRepository,
+	securityAudit repository.ISecurityAuditRepository, mailSender mail.IMailSender, hasher auth.IPasswordHasher,
+	loginGuard ILoginGuard, config AccountConfig) IAccountService {
+	return &AccountServiceImpl{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		securityAudit: securityAudit,
+		mailSender:    mailSender,
+		hasher:        hasher,
+		loginGuard:    loginGuard,
+		config:        config,
+	}
+}
+
+// RequestPasswordReset does not report unknown addresses to the caller so the
+// endpoint cannot be used to discover registered emails.
+func (s *AccountServiceImpl) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		log.Printf("Password reset requested for unknown email: %v", err)
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user, domain.TokenPurposePasswordReset, s.config.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s/reset-password?token=%s\n\nIf you did not request a reset, ignore this message.\n",
+			user.Username, s.config.PasswordResetTTL, s.config.BaseURL, token),
+	}
+	if err := s.mailSender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("error sending password reset mail: %w", err)
+	}
+
+	log.Printf("Password reset mail sent to user %s", user.ID)
+	return nil
+}
+
+// ResetPassword also lifts a login lockout of the account, since whoever holds
+// the mailed token has just proven they own it.
+func (s *AccountServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := checkPassword(newPassword); err != nil {
+		return err
+	}
+
+	userToken, err := s.consumeToken(ctx, token, domain.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userToken.UserID, hashedPassword); err != nil {
+		return fmt.Errorf("error resetting password: %w", err)
+	}
+
+	s.unlockLogin(ctx, userToken.UserID)
+
+	if err := s.tokenRepo.DeleteByUser(ctx, userToken.UserID, domain.TokenPurposePasswordReset); err != nil {
+		log.Printf("Error deleting remaining reset tokens of user %s: %v", userToken.UserID, err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventPasswordReset, userToken.UserID, nil)
+
+	log.Printf("Password reset for user %s", userToken.UserID)
+	return nil
+}
+
+// unlockLogin only logs failures: the password has already been changed.
+func (s *AccountServiceImpl) unlockLogin(ctx context.Context, userID uuid.UUID) {
+	if s.loginGuard == nil {
+		return
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting user %s to unlock after password reset: %v", userID, err)
+		return
+	}
+	if err := s.loginGuard.Unlock(ctx, user.Username); err != nil {
+		log.Printf("Error unlocking user %s after password reset: %v", userID, err)
+	}
+}
+
+func (s *AccountServiceImpl) RequestEmailVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		log.Printf("Email verification requested for unknown email: %v", err)
+		return nil
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user, domain.TokenPurposeEmailVerification, s.config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address. The link expires in %s.\n\n%s/verify-email?token=%s\n",
+			user.Username, s.config.EmailVerificationTTL, s.config.BaseURL, token),
+	}
+	if err := s.mailSender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("error sending verification mail: %w", err)
+	}
+
+	log.Printf("Verification mail sent to user %s", user.ID)
+	return nil
+}
+
+func (s *AccountServiceImpl) VerifyEmail(ctx context.Context, token string) error {
+	userToken, err := s.consumeToken(ctx, token, domain.TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	// The token only confirms the address it was mailed to; after an email
+	// change it is worthless.
+	if err := s.userRepo.VerifyEmail(ctx, userToken.UserID, userToken.Email); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTokenInvalid
+		}
+		return fmt.Errorf("error verifying email: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventEmailVerified, userToken.UserID, nil)
+
+	log.Printf("Email verified for user %s", userToken.UserID)
+	return nil
+}
+
+// issueToken stores only the SHA-256 of the token; the plain value leaves the
+// service exclusively through the mail. The token remembers the address it is
+// mailed to.
+func (s *AccountServiceImpl) issueToken(ctx context.Context, user *domain.User, purpose string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("error generating %s token: %w", purpose, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := s.tokenRepo.DeleteByUser(ctx, user.ID, purpose); err != nil {
+		return "", fmt.Errorf("error invalidating previous %s tokens: %w", purpose, err)
+	}
+
+	userToken := &domain.UserToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokenRepo.Create(ctx, userToken); err != nil {
+		return "", fmt.Errorf("error storing %s token: %w", purpose, err)
+	}
+
+	return token, nil
+}
+
+func (s *AccountServiceImpl) consumeToken(ctx context.Context, token, purpose string) (*domain.UserToken, error) {
+	userToken, err := s.tokenRepo.Consume(ctx, hashToken(token), purpose)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("error checking %s token: %w", purpose, err)
+	}
+	return userToken, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+)
+
+// memoryUserTokenRepo holds single-use tokens by hash.
+type memoryUserTokenRepo struct {
+	repository.IUserTokenRepository
+	tokens map[string]*domain.UserToken
+}
+
+func (r *memoryUserTokenRepo) Consume(ctx context.Context, tokenHash, purpose string) (*domain.UserToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose {
+		return nil, fmt.Errorf("token %w", repository.ErrNotFound)
+	}
+	delete(r.tokens, tokenHash)
+	return token, nil
+}
+
+func (r *memoryUserTokenRepo) DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return nil
+}
+
+// passwordUserRepo remembers the stored password hashes.
+type passwordUserRepo struct {
+	*memoryUserRepo
+	hashes map[uuid.UUID]string
+}
+
+func (r *passwordUserRepo) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	r.hashes[id] = passwordHash
+	return nil
+}
+
+// prefixHasher stands in for argon2, which is far too slow for unit tests.
+type prefixHasher struct {
+	auth.IPasswordHasher
+}
+
+func (prefixHasher) Hash(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func TestResetPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		wantErr    error
+		wantStored bool
+	}{
+		{"too short", "short", ErrInvalidInput, false},
+		{"too long", strings.Repeat("p", maxPasswordLength+1), ErrInvalidInput, false},
+		{"valid", "correct horse", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			user := &domain.User{ID: uuid.New(), Username: "anna"}
+			users := &passwordUserRepo{
+				memoryUserRepo: &memoryUserRepo{users: map[uuid.UUID]*domain.User{user.ID: user}},
+				hashes:         map[uuid.UUID]string{},
+			}
+			tokens := &memoryUserTokenRepo{tokens: map[string]*domain.UserToken{
+				hashToken("reset-token"): {UserID: user.ID, Purpose: domain.TokenPurposePasswordReset},
+			}}
+			guard := NewLoginGuard(newMemoryRedis(), testLoginGuardConfig)
+			for i := 0; i < testLoginGuardConfig.MaxUserAttempts; i++ {
+				if _, err := guard.RegisterFailure(ctx, user.Username, ""); err != nil {
+					t.Fatalf("error registering failure: %v", err)
+				}
+			}
+			accounts := AccountService(users, tokens, &memorySecurityAudit{}, nil, prefixHasher{}, guard, AccountConfig{})
+
+			err := accounts.ResetPassword(ctx, "reset-token", tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResetPassword error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, stored := users.hashes[user.ID]
+			if stored != tt.wantStored {
+				t.Errorf("password stored = %t, want %t", stored, tt.wantStored)
+			}
+
+			checkErr := guard.Check(ctx, user.Username, "")
+			if unlocked := checkErr == nil; unlocked != tt.wantStored {
+				t.Errorf("login unlocked = %t, want %t (Check error %v)", unlocked, tt.wantStored, checkErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyScheme = "lib"
+	// lastUsedResolution keeps busy keys from turning every request into a write.
+	lastUsedResolution = time.Minute
+)
+
+type APIKeyServiceImpl struct {
+	repo         repository.IAPIKeyRepository
+	authzService IAuthzService
+}
+
+func APIKeyService(repo repository.IAPIKeyRepository, authzService IAuthzService) IAPIKeyService {
+	return &APIKeyServiceImpl{
+		repo:         repo,
+		authzService: authzService,
+	}
+}
+
+// Create returns the plain key exactly once; only its hash is stored.
+func (s *APIKeyServiceImpl) Create(ctx context.Context, name string, permissions []string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", fmt.Errorf("%w: api key name must not be empty", ErrInvalidInput)
+	}
+	if len(permissions) == 0 {
+		return nil, "", fmt.Errorf("%w: api key must be scoped to at least one permission", ErrInvalidInput)
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, "", fmt.Errorf("%w: expiry must be in the future", ErrInvalidInput)
+	}
+
+	if err := forbidDuringImpersonation(ctx, "creating api keys"); err != nil {
+		return nil, "", err
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || principal.IsAPIKey() {
+		return nil, "", fmt.Errorf("%w: api keys can only be created by users", ErrForbidden)
+	}
+
+	if err := s.checkGrantable(ctx, principal, permissions); err != nil {
+		return nil, "", err
+	}
+
+	prefix, plainKey, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	createdBy := principal.UserID
+	key := &domain.APIKey{
+		Name:        name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Permissions: permissions,
+		CreatedBy:   &createdBy,
+		ExpiresAt:   expiresAt,
+	}
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("error creating api key: %w", err)
+	}
+
+	recordChange(ctx, "api_key.created", domain.AuditEntityAPIKey, key.ID, nil, key)
+
+	log.Printf("API key %s (%s) created by %s", key.Name, key.ID, createdBy)
+	return key, plainKey, nil
+}
+
+func (s *APIKeyServiceImpl) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	keys, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting list of api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *APIKeyServiceImpl) Rotate(ctx context.Context, id uuid.UUID) (*domain.APIKey, string, error) {
+	if err := forbidDuringImpersonation(ctx, "rotating api keys"); err != nil {
+		return nil, "", err
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, "", fmt.Errorf("%w: authentication required to rotate api keys", ErrForbidden)
+	}
+
+	previous, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting api key to rotate: %w", err)
+	}
+
+	// The new secret carries the permissions of the old key, so rotating is
+	// held to the same rule as creating it.
+	if err := s.checkGrantable(ctx, principal, previous.Permissions); err != nil {
+		return nil, "", err
+	}
+
+	prefix, plainKey, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.repo.Rotate(ctx, id, prefix, hash); err != nil {
+		return nil, "", fmt.Errorf("error rotating api key: %w", err)
+	}
+
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting rotated api key: %w", err)
+	}
+
+	recordChange(ctx, "api_key.rotated", domain.AuditEntityAPIKey, id, previous, key)
+
+	log.Printf("API key %s (%s) rotated", key.Name, key.ID)
+	return key, plainKey, nil
+}
+
+// checkGrantable makes sure a key never holds a permission its issuer lacks.
+// An API key can only pass on its own scope.
+func (s *APIKeyServiceImpl) checkGrantable(ctx context.Context, principal *auth.Principal, permissions []string) error {
+	granted := principal.Permissions
+	if !principal.IsAPIKey() {
+		var err error
+		granted, err = s.authzService.GetUserPermissions(ctx, principal.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	for _, permission := range permissions {
+		if !containsString(granted, permission) {
+			return fmt.Errorf("%w: cannot grant permission %s that you do not hold", ErrForbidden, permission)
+		}
+	}
+	return nil
+}
+
+func (s *APIKeyServiceImpl) Revoke(ctx context.Context, id uuid.UUID) error {
+	if err := forbidDuringImpersonation(ctx, "revoking api keys"); err != nil {
+		return err
+	}
+
+	if err := s.repo.Revoke(ctx, id); err != nil {
+		return fmt.Errorf("error revoking api key: %w", err)
+	}
+
+	recordChange(ctx, "api_key.revoked", domain.AuditEntityAPIKey, id, nil, nil)
+
+	log.Printf("API key %s revoked", id)
+	return nil
+}
+
+func (s *APIKeyServiceImpl) Authenticate(ctx context.Context, plainKey string) (*domain.APIKey, error) {
+	prefix, ok := apiKeyPrefix(plainKey)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("error looking up api key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(plainKey)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("Error recording use of api key %s: %v", key.ID, err)
+		}
+	}
+
+	return key, nil
+}
+
+// generateAPIKey produces keys shaped like lib_<prefix>_<secret>. The prefix
+// is stored in clear so a key can be located without scanning every hash.
+func generateAPIKey() (string, string, string, error) {
+	prefixBytes := make([]byte, 6)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", fmt.Errorf("error generating api key: %w", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("error generating api key: %w", err)
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	plainKey := apiKeyScheme + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return prefix, plainKey, hashToken(plainKey), nil
+}
+
+func apiKeyPrefix(plainKey string) (string, bool) {
+	parts := strings.SplitN(plainKey, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyScheme || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
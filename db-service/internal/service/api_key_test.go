@@ -0,0 +1,80 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"testing"
+)
+
+// memoryAPIKeyRepo holds API keys by ID.
+type memoryAPIKeyRepo struct {
+	repository.IAPIKeyRepository
+	keys map[uuid.UUID]*domain.APIKey
+}
+
+func (r *memoryAPIKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("api key %s %w", id, repository.ErrNotFound)
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (r *memoryAPIKeyRepo) Rotate(ctx context.Context, id uuid.UUID, prefix, keyHash string) error {
+	r.keys[id].Prefix = prefix
+	r.keys[id].KeyHash = keyHash
+	return nil
+}
+
+func TestRotateRequiresKeyPermissions(t *testing.T) {
+	owner := &domain.User{ID: uuid.New(), Username: "owner"}
+	manager := &domain.User{ID: uuid.New(), Username: "manager"}
+	users := &memoryUserRepo{users: map[uuid.UUID]*domain.User{owner.ID: owner, manager.ID: manager}}
+	roles := newMemoryRoleRepo(users)
+	roles.roles["key-manager"] = domain.Role{ID: uuid.New(), Name: "key-manager",
+		Permissions: []string{domain.PermissionKeysManage, "books:read"}}
+	roles.grants[owner.ID] = map[uuid.UUID]bool{roles.roles[domain.RoleAdmin].ID: true}
+	roles.grants[manager.ID] = map[uuid.UUID]bool{roles.roles["key-manager"].ID: true}
+	authz := AuthzService(roles, users, &memorySecurityAudit{})
+
+	keyID := uuid.New()
+	keys := &memoryAPIKeyRepo{keys: map[uuid.UUID]*domain.APIKey{
+		keyID: {ID: keyID, Name: "deploy", Prefix: "old", Permissions: []string{"books:write"}, CreatedBy: &owner.ID},
+	}}
+	apiKeys := APIKeyService(keys, authz)
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		wantErr   error
+	}{
+		{"user without the key's permissions", &auth.Principal{UserID: manager.ID}, ErrForbidden},
+		{"api key without the key's permissions", &auth.Principal{UserID: manager.ID, APIKeyID: &uuid.UUID{},
+			Permissions: []string{domain.PermissionKeysManage}}, ErrForbidden},
+		{"user holding the key's permissions", &auth.Principal{UserID: owner.ID}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			before := keys.keys[keyID].Prefix
+
+			_, plainKey, err := apiKeys.Rotate(ctx, keyID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rotate error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && (plainKey != "" || keys.keys[keyID].Prefix != before) {
+				t.Error("a forbidden rotation replaced the secret")
+			}
+			if tt.wantErr == nil && plainKey == "" {
+				t.Error("Rotate returned no secret")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/cache"
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	apiUsageKeyPrefix = "api_usage:"
+	apiUsageRetention = 90 * 24 * time.Hour
+	apiUsageDayFormat = "2006-01-02"
+	MaxAPIUsageDays   = 90
+)
+
+type APIUsageServiceImpl struct {
+	redisClient cache.IRedisClient
+}
+
+// APIUsageService counts requests per API version and day in Redis, so the
+// counts add up across instances. Without Redis nothing is counted.
+func APIUsageService(redisClient cache.IRedisClient) IAPIUsageService {
+	return &APIUsageServiceImpl{
+		redisClient: redisClient,
+	}
+}
+
+func apiUsageKey(version string, day time.Time) string {
+	return fmt.Sprintf("%s%s:%s", apiUsageKeyPrefix, version, day.UTC().Format(apiUsageDayFormat))
+}
+
+// Record counts one request. Failures are logged and never fail the request.
+func (s *APIUsageServiceImpl) Record(ctx context.Context, version string) {
+	if s.redisClient == nil {
+		return
+	}
+
+	key := apiUsageKey(version, time.Now())
+	count, err := s.redisClient.Incr(ctx, key)
+	if err != nil {
+		log.Printf("Error counting request to API %s: %v", version, err)
+		return
+	}
+	if count == 1 {
+		if err := s.redisClient.Expire(ctx, key, apiUsageRetention); err != nil {
+			log.Printf("Error setting expiration of API usage counter %s: %v", key, err)
+		}
+	}
+}
+
+// GetUsage returns the requests of the last days per version, oldest day
+// first.
+func (s *APIUsageServiceImpl) GetUsage(ctx context.Context, versions []string, days int) ([]domain.APIUsage, error) {
+	if days < 1 || days > MaxAPIUsageDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidInput, MaxAPIUsageDays)
+	}
+	if s.redisClient == nil {
+		return nil, errors.New("API usage is only counted when Redis is configured")
+	}
+
+	today := time.Now().UTC()
+	usage := make([]domain.APIUsage, 0, len(versions))
+	for _, version := range versions {
+		versionUsage := domain.APIUsage{Version: version, Daily: make([]domain.DailyUsage, 0, days)}
+		for offset := days - 1; offset >= 0; offset-- {
+			day := today.AddDate(0, 0, -offset)
+			requests, err := s.count(ctx, apiUsageKey(version, day))
+			if err != nil {
+				return nil, err
+			}
+			versionUsage.Requests += requests
+			versionUsage.Daily = append(versionUsage.Daily, domain.DailyUsage{Date: day.Format(apiUsageDayFormat), Requests: requests})
+		}
+		usage = append(usage, versionUsage)
+	}
+	return usage, nil
+}
+
+func (s *APIUsageServiceImpl) count(ctx context.Context, key string) (int64, error) {
+	value, err := s.redisClient.Get(ctx, key)
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting API usage counter %s: %w", key, err)
+	}
+
+	count, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing API usage counter %s: %w", key, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/requestctx"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditRedacted replaces the values of personal fields in audit diffs.
+const auditRedacted = "[redacted]"
+
+type AuditConfig struct {
+	HashChain bool
+}
+
+type AuditChange struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditScope collects the entity changes made while serving one request, so
+// the request produces audit entries that carry both the HTTP context and the
+// before/after state known only to the services.
+type AuditScope struct {
+	mu      sync.Mutex
+	changes []AuditChange
+}
+
+type RequestAudit struct {
+	Method string
+	Route  string
+	Status int
+}
+
+type auditScopeKey struct{}
+
+func WithAuditScope(ctx context.Context) (context.Context, *AuditScope) {
+	scope := &AuditScope{}
+	return context.WithValue(ctx, auditScopeKey{}, scope), scope
+}
+
+// recordChange is a no-op outside of an audit scope.
+func recordChange(ctx context.Context, action, entityType string, entityID uuid.UUID, before, after interface{}) {
+	scope, ok := ctx.Value(auditScopeKey{}).(*AuditScope)
+	if !ok {
+		return
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.changes = append(scope.changes, AuditChange{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID.String(),
+		Before:     before,
+		After:      after,
+	})
+}
+
+type AuditServiceImpl struct {
+	repo   repository.IAuditRepository
+	config AuditConfig
+}
+
+func AuditService(repo repository.IAuditRepository, config AuditConfig) IAuditService {
+	return &AuditServiceImpl{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// RecordRequest writes one entry per collected change, or a single entry named
+// after the route when the request changed nothing the services know about.
+// Failures are logged and never reach the caller.
+func (s *AuditServiceImpl) RecordRequest(ctx context.Context, scope *AuditScope, request RequestAudit) {
+	scope.mu.Lock()
+	changes := append([]AuditChange(nil), scope.changes...)
+	scope.mu.Unlock()
+
+	if len(changes) == 0 {
+		changes = []AuditChange{{Action: request.Method + " " + request.Route}}
+	}
+
+	client := requestctx.ClientInfoFromContext(ctx)
+	for _, change := range changes {
+		diff, err := auditDiff(change.Before, change.After)
+		if err != nil {
+			log.Printf("Error computing audit diff for %s: %v", change.Action, err)
+			diff = map[string]interface{}{}
+		}
+
+		entry := &domain.AuditEntry{
+			Action:     change.Action,
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			Changes:    diff,
+			Method:     request.Method,
+			Route:      request.Route,
+			Status:     request.Status,
+			IP:         client.IP,
+			RequestID:  client.RequestID,
+		}
+		if principal, ok := auth.PrincipalFromContext(ctx); ok {
+			if principal.IsAPIKey() {
+				entry.APIKeyID = principal.APIKeyID
+			} else {
+				actorID := principal.ActorID()
+				entry.ActorID = &actorID
+			}
+			if principal.IsImpersonated() {
+				onBehalfOf := principal.UserID
+				entry.OnBehalfOf = &onBehalfOf
+			}
+		}
+
+		if err := s.repo.Append(ctx, entry, s.config.HashChain); err != nil {
+			log.Printf("Error writing audit entry %s for request %s: %v", entry.Action, entry.RequestID, err)
+		}
+	}
+}
+
+func (s *AuditServiceImpl) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidInput)
+	}
+
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *AuditServiceImpl) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	result, err := s.repo.Verify(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying audit log: %w", err)
+	}
+	if !result.Valid {
+		log.Printf("Audit log chain broken at entry %d", result.FirstBrokenID)
+	}
+	return result, nil
+}
+
+// auditDiff compares the JSON representations of both states field by field,
+// so whatever a type hides from JSON is hidden from the audit log as well.
+// Personal fields are listed as changed with their values redacted.
+func auditDiff(before, after interface{}) (map[string]interface{}, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	personal := personalFields(before)
+	for field := range personalFields(after) {
+		personal[field] = true
+	}
+	redact := func(field string, value interface{}) interface{} {
+		if value == nil || !personal[field] {
+			return value
+		}
+		return auditRedacted
+	}
+
+	diff := map[string]interface{}{}
+	for field, oldValue := range beforeFields {
+		newValue, ok := afterFields[field]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			diff[field] = map[string]interface{}{"before": redact(field, oldValue), "after": redact(field, newValue)}
+		}
+	}
+	for field, newValue := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			diff[field] = map[string]interface{}{"before": nil, "after": redact(field, newValue)}
+		}
+	}
+	return diff, nil
+}
+
+// personalFields returns the JSON names of the struct fields tagged
+// audit:"personal".
+func personalFields(value interface{}) map[string]bool {
+	fields := map[string]bool{}
+
+	typ := reflect.TypeOf(value)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Tag.Get("audit") != "personal" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		var scalar interface{}
+		if err := json.Unmarshal(raw, &scalar); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"value": scalar}, nil
+	}
+	return fields, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+)
+
+type AuthzServiceImpl struct {
+	roleRepo      repository.IRoleRepository
+	userRepo      repository.IUserRepository
+	securityAudit repository.ISecurityAuditRepository
+}
+
+func AuthzService(roleRepo repository.IRoleRepository, userRepo repository.IUserRepository, securityAudit repository.ISecurityAuditRepository) IAuthzService {
+	return &AuthzServiceImpl{
+		roleRepo:      roleRepo,
+		userRepo:      userRepo,
+		securityAudit: securityAudit,
+	}
+}
+
+func (s *AuthzServiceImpl) HasPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	permissions, err := s.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return containsString(permissions, permission), nil
+}
+
+// Authorize checks the caller attached to the context. API keys are limited to
+// the permissions they were scoped to, users to those of their roles.
+func (s *AuthzServiceImpl) Authorize(ctx context.Context, permission string) (bool, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	if principal.IsAPIKey() {
+		return containsString(principal.Permissions, permission), nil
+	}
+
+	return s.HasPermission(ctx, principal.UserID, permission)
+}
+
+// AuthorizeOwner lets users act on what belongs to them. Anyone else, API keys
+// included, needs the given permission.
+func (s *AuthzServiceImpl) AuthorizeOwner(ctx context.Context, ownerID uuid.UUID, permission string) (bool, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if ok && !principal.IsAPIKey() && principal.UserID == ownerID {
+		return true, nil
+	}
+
+	return s.Authorize(ctx, permission)
+}
+
+// GetUserPermissions reads the roles only. The legacy is_admin flag mirrors the
+// admin role and grants nothing by itself.
+func (s *AuthzServiceImpl) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	permissions, err := s.roleRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+func (s *AuthzServiceImpl) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
+	roles, err := s.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *AuthzServiceImpl) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting list of roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *AuthzServiceImpl) GrantRole(ctx context.Context, userID uuid.UUID, roleName string) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return fmt.Errorf("user to grant role not found: %w", err)
+	}
+
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return fmt.Errorf("role to grant not found: %w", err)
+	}
+
+	var grantedBy *uuid.UUID
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && !principal.IsAPIKey() {
+		actorID := principal.ActorID()
+		grantedBy = &actorID
+	}
+
+	if err := s.roleRepo.AssignToUser(ctx, userID, role.ID, grantedBy); err != nil {
+		return fmt.Errorf("error granting role: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventRoleGranted, userID, map[string]interface{}{
+		"role": role.Name,
+	})
+	recordChange(ctx, "role.granted", domain.AuditEntityRole, userID, nil, map[string]string{"role": role.Name})
+
+	log.Printf("Role %s granted to user %s", role.Name, userID)
+	return nil
+}
+
+func (s *AuthzServiceImpl) RevokeRole(ctx context.Context, userID uuid.UUID, roleName string) error {
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return fmt.Errorf("role to revoke not found: %w", err)
+	}
+
+	if err := s.roleRepo.RemoveFromUser(ctx, userID, role.ID); err != nil {
+		return fmt.Errorf("error revoking role: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventRoleRevoked, userID, map[string]interface{}{
+		"role": role.Name,
+	})
+	recordChange(ctx, "role.revoked", domain.AuditEntityRole, userID, map[string]string{"role": role.Name}, nil)
+
+	log.Printf("Role %s revoked from user %s", role.Name, userID)
+	return nil
+}
+
+// SyncProviderRoles makes the roles an identity provider granted match the
+// ones its claims map to now. Roles granted directly are neither touched nor
+// granted again.
+func (s *AuthzServiceImpl) SyncProviderRoles(ctx context.Context, userID uuid.UUID, provider string, roleNames []string) error {
+	current, err := s.roleRepo.GetProviderRoles(ctx, userID, provider)
+	if err != nil {
+		return fmt.Errorf("error getting roles granted by %s: %w", provider, err)
+	}
+
+	wanted := make(map[string]bool, len(roleNames))
+	for _, name := range roleNames {
+		wanted[name] = true
+	}
+
+	var errs []error
+	for _, role := range current {
+		if wanted[role.Name] {
+			delete(wanted, role.Name)
+			continue
+		}
+		removed, err := s.roleRepo.RemoveFromProvider(ctx, userID, role.ID, provider)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if removed {
+			s.recordProviderRole(ctx, false, userID, role.Name, provider)
+		}
+	}
+
+	for name := range wanted {
+		role, err := s.roleRepo.GetByName(ctx, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("role to grant not found: %w", err))
+			continue
+		}
+		granted, err := s.roleRepo.AssignFromProvider(ctx, userID, role.ID, provider)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if granted {
+			s.recordProviderRole(ctx, true, userID, role.Name, provider)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *AuthzServiceImpl) recordProviderRole(ctx context.Context, granted bool, userID uuid.UUID, roleName, provider string) {
+	details := map[string]interface{}{"role": roleName, "provider": provider}
+	change := map[string]string{"role": roleName, "provider": provider}
+
+	if granted {
+		recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventRoleGranted, userID, details)
+		recordChange(ctx, "role.granted", domain.AuditEntityRole, userID, nil, change)
+		log.Printf("Role %s granted to user %s by identity provider %s", roleName, userID, provider)
+		return
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventRoleRevoked, userID, details)
+	recordChange(ctx, "role.revoked", domain.AuditEntityRole, userID, change, nil)
+	log.Printf("Role %s revoked from user %s by identity provider %s", roleName, userID, provider)
+}
+
+// requireOwnerOfAll applies requireOwner to a batch of users. Only the
+// caller's own account is exempt, so a single check covers all the others.
+func requireOwnerOfAll(ctx context.Context, authzService IAuthzService, ownerIDs []uuid.UUID, permission string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	for _, id := range ownerIDs {
+		if ok && !principal.IsAPIKey() && principal.UserID == id {
+			continue
+		}
+		return requireOwner(ctx, authzService, id, permission)
+	}
+	return nil
+}
+
+// forbidDuringImpersonation keeps impersonated sessions away from credentials
+// and other account-level changes only the real user may make.
+func forbidDuringImpersonation(ctx context.Context, action string) error {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.IsImpersonated() {
+		return fmt.Errorf("%w: %s is not allowed while impersonating", ErrForbidden, action)
+	}
+	return nil
+}
+
+func requireOwner(ctx context.Context, authzService IAuthzService, ownerID uuid.UUID, permission string) error {
+	allowed, err := authzService.AuthorizeOwner(ctx, ownerID, permission)
+	if err != nil {
+		return fmt.Errorf("error checking permission %s: %w", permission, err)
+	}
+	if !allowed {
+		return fmt.Errorf("%w: access to user %s requires %s", ErrForbidden, ownerID, permission)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"testing"
+)
+
+// memorySecurityAudit keeps the recorded security events.
+type memorySecurityAudit struct {
+	events []*domain.SecurityEvent
+}
+
+func (a *memorySecurityAudit) Record(ctx context.Context, event *domain.SecurityEvent) error {
+	a.events = append(a.events, event)
+	return nil
+}
+
+func (a *memorySecurityAudit) has(eventType string) bool {
+	for _, event := range a.events {
+		if event.EventType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryUserRepo holds users by ID. Methods the tests do not need panic
+// through the nil embedded interface.
+type memoryUserRepo struct {
+	repository.IUserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (r *memoryUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user %s %w", id, repository.ErrNotFound)
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// memoryRoleRepo stores role grants and mirrors the admin role into the
+// is_admin flag of its users the way the database does.
+type memoryRoleRepo struct {
+	repository.IRoleRepository
+	roles  map[string]domain.Role
+	grants map[uuid.UUID]map[uuid.UUID]bool
+	users  *memoryUserRepo
+}
+
+func newMemoryRoleRepo(users *memoryUserRepo) *memoryRoleRepo {
+	return &memoryRoleRepo{
+		roles: map[string]domain.Role{
+			domain.RoleAdmin:  {ID: uuid.New(), Name: domain.RoleAdmin, Permissions: []string{"books:write", domain.PermissionRolesManage}},
+			domain.RoleMember: {ID: uuid.New(), Name: domain.RoleMember, Permissions: []string{"books:read"}},
+		},
+		grants: map[uuid.UUID]map[uuid.UUID]bool{},
+		users:  users,
+	}
+}
+
+func (r *memoryRoleRepo) GetByName(ctx context.Context, name string) (*domain.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, fmt.Errorf("role %s %w", name, repository.ErrNotFound)
+	}
+	return &role, nil
+}
+
+func (r *memoryRoleRepo) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	permissions := []string{}
+	for _, role := range r.roles {
+		if r.grants[userID][role.ID] {
+			permissions = append(permissions, role.Permissions...)
+		}
+	}
+	return permissions, nil
+}
+
+func (r *memoryRoleRepo) AssignToUser(ctx context.Context, userID, roleID uuid.UUID, grantedBy *uuid.UUID) error {
+	if r.grants[userID] == nil {
+		r.grants[userID] = map[uuid.UUID]bool{}
+	}
+	r.grants[userID][roleID] = true
+	r.syncAdminFlag(userID)
+	return nil
+}
+
+func (r *memoryRoleRepo) RemoveFromUser(ctx context.Context, userID, roleID uuid.UUID) error {
+	delete(r.grants[userID], roleID)
+	r.syncAdminFlag(userID)
+	return nil
+}
+
+func (r *memoryRoleRepo) syncAdminFlag(userID uuid.UUID) {
+	if user, ok := r.users.users[userID]; ok {
+		user.IsAdmin = r.grants[userID][r.roles[domain.RoleAdmin].ID]
+	}
+}
+
+func TestRevokeAdminRemovesPermissionsOfMigratedAdmin(t *testing.T) {
+	ctx := context.Background()
+	admin := &domain.User{ID: uuid.New(), Username: "root", IsAdmin: true}
+	users := &memoryUserRepo{users: map[uuid.UUID]*domain.User{admin.ID: admin}}
+	roles := newMemoryRoleRepo(users)
+	// Migration 0000002 copied every is_admin user into the admin role.
+	roles.grants[admin.ID] = map[uuid.UUID]bool{roles.roles[domain.RoleAdmin].ID: true}
+	authz := AuthzService(roles, users, &memorySecurityAudit{})
+
+	if ok, err := authz.HasPermission(ctx, admin.ID, domain.PermissionRolesManage); err != nil || !ok {
+		t.Fatalf("migrated admin: HasPermission = (%t, %v), want (true, nil)", ok, err)
+	}
+
+	if err := authz.RevokeRole(ctx, admin.ID, domain.RoleAdmin); err != nil {
+		t.Fatalf("error revoking admin: %v", err)
+	}
+
+	permissions, err := authz.GetUserPermissions(ctx, admin.ID)
+	if err != nil {
+		t.Fatalf("error getting permissions: %v", err)
+	}
+	if len(permissions) != 0 {
+		t.Errorf("permissions after revoking admin = %v, want none", permissions)
+	}
+	if admin.IsAdmin {
+		t.Error("is_admin is still set after revoking admin")
+	}
+}
+
+func TestGrantRoleSetsAdminFlag(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Username: "anna"}
+	users := &memoryUserRepo{users: map[uuid.UUID]*domain.User{user.ID: user}}
+	authz := AuthzService(newMemoryRoleRepo(users), users, &memorySecurityAudit{})
+
+	tests := []struct {
+		role      string
+		wantAdmin bool
+		wantWrite bool
+	}{
+		{domain.RoleMember, false, false},
+		{domain.RoleAdmin, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			if err := authz.GrantRole(ctx, user.ID, tt.role); err != nil {
+				t.Fatalf("error granting %s: %v", tt.role, err)
+			}
+			if user.IsAdmin != tt.wantAdmin {
+				t.Errorf("is_admin = %t, want %t", user.IsAdmin, tt.wantAdmin)
+			}
+			if ok, _ := authz.HasPermission(ctx, user.ID, "books:write"); ok != tt.wantWrite {
+				t.Errorf("books:write = %t, want %t", ok, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestAdminFlagAloneGrantsNothing(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "legacy", IsAdmin: true}
+	users := &memoryUserRepo{users: map[uuid.UUID]*domain.User{user.ID: user}}
+	authz := AuthzService(newMemoryRoleRepo(users), users, &memorySecurityAudit{})
+
+	if ok, err := authz.HasPermission(context.Background(), user.ID, domain.PermissionRolesManage); err != nil || ok {
+		t.Errorf("HasPermission = (%t, %v), want (false, nil)", ok, err)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+type BatchItemError = repository.BatchItemError
+
+// BookBatchOperation is one item of a book batch. Update and delete address the
+// book by ID and Version, where repository.AnyVersion skips the check.
+type BookBatchOperation struct {
+	Op      string
+	ID      uuid.UUID
+	Version int64
+	Book    *domain.Book
+}
+
+// UserBatchOperation is one item of a user batch. IsAdmin left nil keeps the
+// current flag on updates, and an empty Password keeps the current password.
+type UserBatchOperation struct {
+	Op       string
+	ID       uuid.UUID
+	Version  int64
+	Username string
+	Email    string
+	Password string
+	IsAdmin  *bool
+}
+
+type BatchResult struct {
+	Op      string
+	ID      uuid.UUID
+	Version int64
+}
+
+// batchItemError ties an error to its item. Validation errors name their
+// fields after the item, e.g. items[2].name, so clients can point at them.
+func batchItemError(index int, err error) error {
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		fields := make([]FieldError, len(validation.Fields))
+		for i, field := range validation.Fields {
+			fields[i] = FieldError{Field: fmt.Sprintf("items[%d].%s", index, field.Field), Message: field.Message}
+		}
+		return &ValidationError{Fields: fields}
+	}
+	return &BatchItemError{Index: index, Err: err}
+}
+
+// checkBatchTarget rejects batches that address the same record twice, since
+// the second write would always fail the version check.
+func checkBatchTarget(id uuid.UUID, seen map[uuid.UUID]bool) error {
+	if id == uuid.Nil {
+		return fmt.Errorf("%w: id is required", ErrInvalidInput)
+	}
+	if seen[id] {
+		return fmt.Errorf("%w: %s appears more than once in the batch", ErrInvalidInput, id)
+	}
+	seen[id] = true
+	return nil
+}
+
+func checkBatchSize(size int) error {
+	if size == 0 || size > domain.MaxBatchSize {
+		return fmt.Errorf("%w: a batch must hold between 1 and %d items", ErrInvalidInput, domain.MaxBatchSize)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"log"
+	"time"
 )
 
 type BookServiceImpl struct {
@@ -38,7 +39,57 @@ func (s *BookServiceImpl) GetAll(ctx context.Context, author, genre string) ([]*
 	return books, nil
 }
 
+// LastModified returns the time of the latest change to any book, deletions
+// included.
+func (s *BookServiceImpl) LastModified(ctx context.Context) (time.Time, error) {
+	lastModified, err := s.bookRepo.LastModified(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting last change of the catalog: %w", err)
+	}
+	return lastModified, nil
+}
+
+// GetPage returns one page of the books matching the filters and the number
+// of all matching books.
+func (s *BookServiceImpl) GetPage(ctx context.Context, author, genre string, limit, offset int) ([]*domain.Book, int, error) {
+	books, total, err := s.bookRepo.GetPage(ctx, author, genre, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting page of books: %w", err)
+	}
+	return books, total, nil
+}
+
+// GetByIDs, GetByAuthors and GetLoans read many books at once for the GraphQL
+// API. Books missing from the result do not exist.
+func (s *BookServiceImpl) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Book, error) {
+	books, err := s.bookRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting books by ID: %w", err)
+	}
+	return books, nil
+}
+
+func (s *BookServiceImpl) GetByAuthors(ctx context.Context, authors []string) ([]*domain.Book, error) {
+	books, err := s.bookRepo.GetByAuthors(ctx, authors)
+	if err != nil {
+		return nil, fmt.Errorf("error getting books by author: %w", err)
+	}
+	return books, nil
+}
+
+func (s *BookServiceImpl) GetLoans(ctx context.Context, bookIDs []uuid.UUID) ([]domain.UserBook, error) {
+	loans, err := s.bookRepo.GetLoans(ctx, bookIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting loans of books: %w", err)
+	}
+	return loans, nil
+}
+
 func (s *BookServiceImpl) Create(ctx context.Context, book *domain.Book) error {
+	if err := Validate(book); err != nil {
+		return err
+	}
+
 	if book.ID == uuid.Nil {
 		book.ID = uuid.New()
 	}
@@ -47,6 +98,8 @@ func (s *BookServiceImpl) Create(ctx context.Context, book *domain.Book) error {
 		return fmt.Errorf("error creating book: %w", err)
 	}
 
+	recordChange(ctx, "book.created", domain.AuditEntityBook, book.ID, nil, book)
+
 	if err := s.eventProducer.PublishBookCreated(ctx, book); err != nil {
 		log.Printf("Error publishing book creation event: %v", err)
 	} else {
@@ -57,14 +110,17 @@ func (s *BookServiceImpl) Create(ctx context.Context, book *domain.Book) error {
 }
 
 func (s *BookServiceImpl) Update(ctx context.Context, book *domain.Book) error {
+	if err := Validate(book); err != nil {
+		return err
+	}
 
-	_, err := s.bookRepo.GetByID(ctx, book.ID)
+	currentBook, err := s.bookRepo.GetByID(ctx, book.ID)
 	if err != nil {
 		return fmt.Errorf("Book for update not found: %w", err)
 	}
 
-	if err := s.bookRepo.Update(ctx, book); err != nil {
-		return fmt.Errorf("error updating book: %w", err)
+	if err := s.update(ctx, currentBook, book); err != nil {
+		return err
 	}
 
 	if err := s.eventProducer.PublishBookUpdated(ctx, book); err != nil {
@@ -76,17 +132,70 @@ func (s *BookServiceImpl) Update(ctx context.Context, book *domain.Book) error {
 	return nil
 }
 
-func (s *BookServiceImpl) Delete(ctx context.Context, id uuid.UUID) error {
+// Patch applies a merge patch or JSON patch to the book. Consumers of
+// book.updated get the full book as after a PUT; book.patched additionally
+// lists only the fields that changed.
+func (s *BookServiceImpl) Patch(ctx context.Context, id uuid.UUID, version int64, mediaType string, patch []byte) (*domain.Book, error) {
+	currentBook, err := s.bookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("book to patch not found: %w", err)
+	}
+	if err := checkVersion(currentBook.Version, version); err != nil {
+		return nil, err
+	}
 
+	original := bookDocument{Genre: currentBook.Genre, Name: currentBook.Name, Author: currentBook.Author, Year: currentBook.Year}
+	var patched bookDocument
+	if err := applyPatch(original, mediaType, patch, &patched); err != nil {
+		return nil, err
+	}
+
+	book := &domain.Book{ID: id, Genre: patched.Genre, Name: patched.Name, Author: patched.Author, Year: patched.Year,
+		Version: currentBook.Version, UpdatedAt: currentBook.UpdatedAt}
+	if err := Validate(book); err != nil {
+		return nil, err
+	}
+	if *book == *currentBook {
+		return book, nil
+	}
+
+	if err := s.update(ctx, currentBook, book); err != nil {
+		return nil, err
+	}
+
+	if err := s.eventProducer.PublishBookUpdated(ctx, book); err != nil {
+		log.Printf("Error publishing book update event: %v", err)
+	}
+	if err := s.eventProducer.PublishBookPatched(ctx, currentBook, book); err != nil {
+		log.Printf("Error publishing book patch event: %v", err)
+	} else {
+		log.Printf("Book patch event published: %s (%s)", book.Name, book.ID)
+	}
+
+	return book, nil
+}
+
+func (s *BookServiceImpl) update(ctx context.Context, currentBook, book *domain.Book) error {
+	if err := s.bookRepo.Update(ctx, book); err != nil {
+		return fmt.Errorf("error updating book: %w", err)
+	}
+
+	recordChange(ctx, "book.updated", domain.AuditEntityBook, book.ID, currentBook, book)
+	return nil
+}
+
+func (s *BookServiceImpl) Delete(ctx context.Context, id uuid.UUID, version int64) error {
 	book, err := s.bookRepo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("book to delete not found: %w", err)
 	}
 
-	if err := s.bookRepo.Delete(ctx, id); err != nil {
+	if err := s.bookRepo.Delete(ctx, id, version); err != nil {
 		return fmt.Errorf("error deleting book: %w", err)
 	}
 
+	recordChange(ctx, "book.deleted", domain.AuditEntityBook, id, book, nil)
+
 	if err := s.eventProducer.PublishBookDeleted(ctx, id); err != nil {
 		log.Printf("Error publishing book deletion event: %v", err)
 	} else {
@@ -95,3 +204,93 @@ func (s *BookServiceImpl) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// ApplyBatch creates, updates and deletes books in one transaction. Audit
+// records and events only follow once every item has been written.
+func (s *BookServiceImpl) ApplyBatch(ctx context.Context, operations []BookBatchOperation) ([]BatchResult, error) {
+	if err := checkBatchSize(len(operations)); err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.BookBatchItem, len(operations))
+	seen := map[uuid.UUID]bool{}
+	for i, operation := range operations {
+		item, err := s.prepareBatchItem(ctx, operation, seen)
+		if err != nil {
+			return nil, batchItemError(i, err)
+		}
+		items[i] = item
+	}
+
+	if err := s.bookRepo.ApplyBatch(ctx, items); err != nil {
+		return nil, fmt.Errorf("error applying book batch: %w", err)
+	}
+
+	results := make([]BatchResult, len(items))
+	for i, item := range items {
+		results[i] = BatchResult{Op: item.Op, ID: item.Book.ID, Version: item.Book.Version}
+		s.publishBatchItem(ctx, item)
+	}
+	log.Printf("Book batch applied: %d items", len(items))
+
+	return results, nil
+}
+
+func (s *BookServiceImpl) prepareBatchItem(ctx context.Context, operation BookBatchOperation, seen map[uuid.UUID]bool) (domain.BookBatchItem, error) {
+	item := domain.BookBatchItem{Op: operation.Op, Book: operation.Book}
+
+	switch operation.Op {
+	case domain.BatchCreate, domain.BatchUpdate:
+		if item.Book == nil {
+			return item, fmt.Errorf("%w: book is required", ErrInvalidInput)
+		}
+		if err := Validate(item.Book); err != nil {
+			return item, err
+		}
+	case domain.BatchDelete:
+		item.Book = &domain.Book{}
+	default:
+		return item, fmt.Errorf("%w: unknown operation %q", ErrInvalidInput, operation.Op)
+	}
+
+	if operation.Op == domain.BatchCreate {
+		item.Book.ID = uuid.New()
+		return item, nil
+	}
+
+	if err := checkBatchTarget(operation.ID, seen); err != nil {
+		return item, err
+	}
+
+	currentBook, err := s.bookRepo.GetByID(ctx, operation.ID)
+	if err != nil {
+		return item, fmt.Errorf("book to %s not found: %w", operation.Op, err)
+	}
+	if err := checkVersion(currentBook.Version, operation.Version); err != nil {
+		return item, err
+	}
+
+	// The stored version pins the write to the book read here.
+	item.Book.ID = operation.ID
+	item.Book.Version = currentBook.Version
+	item.Current = currentBook
+	return item, nil
+}
+
+func (s *BookServiceImpl) publishBatchItem(ctx context.Context, item domain.BookBatchItem) {
+	var err error
+	switch item.Op {
+	case domain.BatchCreate:
+		recordChange(ctx, "book.created", domain.AuditEntityBook, item.Book.ID, nil, item.Book)
+		err = s.eventProducer.PublishBookCreated(ctx, item.Book)
+	case domain.BatchUpdate:
+		recordChange(ctx, "book.updated", domain.AuditEntityBook, item.Book.ID, item.Current, item.Book)
+		err = s.eventProducer.PublishBookUpdated(ctx, item.Book)
+	case domain.BatchDelete:
+		recordChange(ctx, "book.deleted", domain.AuditEntityBook, item.Book.ID, item.Current, nil)
+		err = s.eventProducer.PublishBookDeleted(ctx, item.Book.ID)
+	}
+	if err != nil {
+		log.Printf("Error publishing book %s event for %s: %v", item.Op, item.Book.ID, err)
+	}
+}
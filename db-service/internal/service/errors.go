@@ -0,0 +1,58 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/validation"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidCredentials = fmt.Errorf("%w: invalid credentials", ErrUnauthorized)
+	ErrInvalidInput       = errors.New("invalid input")
+	ErrTokenInvalid       = fmt.Errorf("%w: invalid or expired token", ErrUnauthorized)
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+type FieldError = validation.FieldError
+
+// ValidationError lists every rejected field at once. It matches
+// ErrInvalidInput.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidInput, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// Validate applies the `validate` tags of v and reports all violations as a
+// single ValidationError.
+func Validate(v interface{}) error {
+	if fields := validation.Struct(v); len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// checkVersion compares the stored version with the one the client based its
+// change on. repository.AnyVersion accepts every version.
+func checkVersion(current, expected int64) error {
+	if expected != repository.AnyVersion && current != expected {
+		return fmt.Errorf("%w: version %d is stale, current version is %d", ErrPreconditionFailed, expected, current)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/cache"
+	"awesomeProject22/db-service/internal/domain"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyKeyPrefix    = "idempotency:"
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+type IdempotencyConfig struct {
+	// TTL is how long a completed response is replayed.
+	TTL time.Duration
+	// LockTTL bounds how long a request may hold its key while it runs, so a
+	// crashed instance does not block the key forever.
+	LockTTL time.Duration
+	// WaitTimeout is how long a concurrent duplicate waits for the first
+	// request before it gives up with a conflict.
+	WaitTimeout time.Duration
+}
+
+type IdempotencyServiceImpl struct {
+	redisClient cache.IRedisClient
+	config      IdempotencyConfig
+}
+
+// IdempotencyService stores requests sent with an Idempotency-Key in Redis, so
+// retries are answered with the first response on every instance. Without
+// Redis every request runs.
+func IdempotencyService(redisClient cache.IRedisClient, config IdempotencyConfig) IIdempotencyService {
+	return &IdempotencyServiceImpl{
+		redisClient: redisClient,
+		config:      config,
+	}
+}
+
+func idempotencyRedisKey(scope, key string) string {
+	return fmt.Sprintf("%s%s:%s", idempotencyKeyPrefix, scope, key)
+}
+
+// Begin claims the key for a request. It returns nil when the caller should
+// run the request and Complete or Abandon the key afterwards, or the stored
+// record of the first request to replay. A duplicate that arrives while the
+// first request still runs waits for its response.
+func (s *IdempotencyServiceImpl) Begin(ctx context.Context, scope, key, fingerprint string) (*domain.IdempotencyRecord, error) {
+	if s.redisClient == nil {
+		return nil, nil
+	}
+
+	redisKey := idempotencyRedisKey(scope, key)
+	pending, err := json.Marshal(domain.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("error serializing idempotency record: %w", err)
+	}
+
+	deadline := time.Now().Add(s.config.WaitTimeout)
+	for {
+		claimed, err := s.redisClient.SetNX(ctx, redisKey, string(pending), s.config.LockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("error claiming idempotency key: %w", err)
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		record, err := s.get(ctx, redisKey)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			if record.Fingerprint != fingerprint {
+				return nil, ErrIdempotencyKeyReused
+			}
+			if record.Completed {
+				return record, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: a request with this idempotency key is still in progress", ErrConflict)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// get returns nil when the key expired or was abandoned in the meantime.
+func (s *IdempotencyServiceImpl) get(ctx context.Context, redisKey string) (*domain.IdempotencyRecord, error) {
+	value, err := s.redisClient.Get(ctx, redisKey)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading idempotency key: %w", err)
+	}
+
+	var record domain.IdempotencyRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return nil, fmt.Errorf("error deserializing idempotency record: %w", err)
+	}
+	return &record, nil
+}
+
+// Complete stores the response for replays. Callers decide which responses are
+// final. Failures are logged; the request itself has already succeeded.
+func (s *IdempotencyServiceImpl) Complete(ctx context.Context, scope, key string, record *domain.IdempotencyRecord) {
+	if s.redisClient == nil {
+		return
+	}
+
+	record.Completed = true
+	value, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Error serializing idempotent response: %v", err)
+		return
+	}
+	if err := s.redisClient.Set(ctx, idempotencyRedisKey(scope, key), string(value), s.config.TTL); err != nil {
+		log.Printf("Error storing idempotent response: %v", err)
+	}
+}
+
+// Abandon releases the key so the request can be retried.
+func (s *IdempotencyServiceImpl) Abandon(ctx context.Context, scope, key string) {
+	if s.redisClient == nil {
+		return
+	}
+
+	if err := s.redisClient.Delete(ctx, idempotencyRedisKey(scope, key)); err != nil {
+		log.Printf("Error releasing idempotency key: %v", err)
+	}
+}
+
+// Forget deletes the stored requests of a user and every stored response that
+// names the user, such as the body of the request that created the account.
+func (s *IdempotencyServiceImpl) Forget(ctx context.Context, userID uuid.UUID) error {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	keys, err := s.redisClient.Scan(ctx, idempotencyKeyPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("error listing idempotency keys: %w", err)
+	}
+
+	ownScope := idempotencyKeyPrefix + "user:" + userID.String() + ":"
+	id := []byte(userID.String())
+
+	var forget []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, ownScope) {
+			forget = append(forget, key)
+			continue
+		}
+		record, err := s.get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if record != nil && bytes.Contains(record.Body, id) {
+			forget = append(forget, key)
+		}
+	}
+
+	if len(forget) == 0 {
+		return nil
+	}
+	if err := s.redisClient.Delete(ctx, forget...); err != nil {
+		return fmt.Errorf("error deleting idempotency keys: %w", err)
+	}
+	return nil
+}
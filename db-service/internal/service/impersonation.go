@@ -0,0 +1,100 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+type ImpersonationConfig struct {
+	TTL time.Duration
+}
+
+type Impersonation struct {
+	Token     string    `json:"token"`
+	UserID    uuid.UUID `json:"user_id"`
+	ActorID   uuid.UUID `json:"actor_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ImpersonationServiceImpl struct {
+	userRepo      repository.IUserRepository
+	tokenManager  auth.ITokenManager
+	authzService  IAuthzService
+	securityAudit repository.ISecurityAuditRepository
+	config        ImpersonationConfig
+}
+
+func ImpersonationService(userRepo repository.IUserRepository, tokenManager auth.ITokenManager, authzService IAuthzService,
+	securityAudit repository.ISecurityAuditRepository, config ImpersonationConfig) IImpersonationService {
+	return &ImpersonationServiceImpl{
+		userRepo:      userRepo,
+		tokenManager:  tokenManager,
+		authzService:  authzService,
+		securityAudit: securityAudit,
+		config:        config,
+	}
+}
+
+// Start issues a short-lived token for the target user that still names the
+// calling administrator. Only users whose permissions the administrator holds
+// can be impersonated, so impersonation never widens access.
+func (s *ImpersonationServiceImpl) Start(ctx context.Context, targetID uuid.UUID) (*Impersonation, error) {
+	if err := forbidDuringImpersonation(ctx, "starting another impersonation"); err != nil {
+		return nil, err
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || principal.IsAPIKey() {
+		return nil, fmt.Errorf("%w: impersonation must be started by a user", ErrForbidden)
+	}
+	if principal.UserID == targetID {
+		return nil, fmt.Errorf("%w: cannot impersonate yourself", ErrInvalidInput)
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, targetID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: user %s", ErrNotFound, targetID)
+		}
+		return nil, fmt.Errorf("error getting user to impersonate: %w", err)
+	}
+
+	actorPermissions, err := s.authzService.GetUserPermissions(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	targetPermissions, err := s.authzService.GetUserPermissions(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range targetPermissions {
+		if !containsString(actorPermissions, permission) {
+			return nil, fmt.Errorf("%w: user %s holds %s which you do not have", ErrForbidden, targetID, permission)
+		}
+	}
+
+	token, err := s.tokenManager.IssueImpersonation(targetID, principal.UserID, s.config.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("error issuing impersonation token: %w", err)
+	}
+	expiresAt := time.Now().Add(s.config.TTL).UTC()
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventImpersonation, targetID, map[string]interface{}{
+		"expires_at": expiresAt,
+	})
+	recordChange(ctx, "user.impersonated", domain.AuditEntityUser, targetID, nil, nil)
+
+	log.Printf("User %s started impersonating user %s until %s", principal.UserID, targetID, expiresAt)
+	return &Impersonation{
+		Token:     token,
+		UserID:    targetID,
+		ActorID:   principal.UserID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
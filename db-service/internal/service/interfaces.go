@@ -4,22 +4,115 @@ import (
 	"awesomeProject22/db-service/internal/domain"
 	"context"
 	"github.com/google/uuid"
+	"time"
 )
 
 type IUserService interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error)
+	GetLoans(ctx context.Context, userIDs []uuid.UUID) ([]domain.UserBook, error)
 	Create(ctx context.Context, user *domain.User, password string) error
 	Update(ctx context.Context, user *domain.User, passwordChanged bool, newPassword string) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	Authenticate(ctx context.Context, username, password string) (string, error)
+	Patch(ctx context.Context, id uuid.UUID, version int64, mediaType string, patch []byte) (*domain.User, error)
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	Authenticate(ctx context.Context, username, password string) (*AuthResult, error)
+	CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string) (string, error)
+	StartTwoFactorEnrollment(ctx context.Context, challengeToken string) (*Enrollment, error)
+	CompleteTwoFactorEnrollment(ctx context.Context, challengeToken, code string) (string, []string, error)
+	CompleteExternalLogin(ctx context.Context, id uuid.UUID) (*AuthResult, error)
 	IsAdmin(ctx context.Context, id uuid.UUID) (bool, error)
+	EnsureActive(ctx context.Context, id uuid.UUID) error
 	GetAll(ctx context.Context) ([]domain.User, error)
+	GetPage(ctx context.Context, limit, offset int) ([]domain.User, int, error)
+	Unlock(ctx context.Context, id uuid.UUID) error
+	ApplyBatch(ctx context.Context, operations []UserBatchOperation) ([]BatchResult, error)
 }
 
 type IBookService interface {
 	GetAll(ctx context.Context, author, genre string) ([]*domain.Book, error)
+	GetPage(ctx context.Context, author, genre string, limit, offset int) ([]*domain.Book, int, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Book, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Book, error)
+	GetByAuthors(ctx context.Context, authors []string) ([]*domain.Book, error)
+	GetLoans(ctx context.Context, bookIDs []uuid.UUID) ([]domain.UserBook, error)
 	Create(ctx context.Context, book *domain.Book) error
 	Update(ctx context.Context, book *domain.Book) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Patch(ctx context.Context, id uuid.UUID, version int64, mediaType string, patch []byte) (*domain.Book, error)
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	ApplyBatch(ctx context.Context, operations []BookBatchOperation) ([]BatchResult, error)
+	LastModified(ctx context.Context) (time.Time, error)
+}
+
+type IAuthzService interface {
+	HasPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error)
+	Authorize(ctx context.Context, permission string) (bool, error)
+	AuthorizeOwner(ctx context.Context, ownerID uuid.UUID, permission string) (bool, error)
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error)
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	GrantRole(ctx context.Context, userID uuid.UUID, roleName string) error
+	RevokeRole(ctx context.Context, userID uuid.UUID, roleName string) error
+	SyncProviderRoles(ctx context.Context, userID uuid.UUID, provider string, roleNames []string) error
+}
+
+type IAccountService interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	RequestEmailVerification(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+}
+
+type ITwoFactorService interface {
+	Enroll(ctx context.Context, userID uuid.UUID) (*Enrollment, error)
+	Activate(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	Disable(ctx context.Context, userID uuid.UUID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error)
+	Verify(ctx context.Context, userID uuid.UUID, code string) error
+}
+
+type IAPIKeyService interface {
+	Create(ctx context.Context, name string, permissions []string, expiresAt *time.Time) (*domain.APIKey, string, error)
+	GetAll(ctx context.Context) ([]domain.APIKey, error)
+	Rotate(ctx context.Context, id uuid.UUID) (*domain.APIKey, string, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	Authenticate(ctx context.Context, plainKey string) (*domain.APIKey, error)
+}
+
+type IOIDCService interface {
+	BeginLogin(ctx context.Context, provider string) (string, error)
+	CompleteLogin(ctx context.Context, provider, state, code string) (*AuthResult, error)
+}
+
+type IPrivacyService interface {
+	Export(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error)
+	Erase(ctx context.Context, userID uuid.UUID) error
+}
+
+type IImpersonationService interface {
+	Start(ctx context.Context, targetID uuid.UUID) (*Impersonation, error)
+}
+
+type ILoginMonitorService interface {
+	RecordLogin(ctx context.Context, user *domain.User) *domain.LoginRecord
+	GetDevices(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error)
+	GetHistory(ctx context.Context, userID uuid.UUID) ([]domain.LoginRecord, error)
+}
+
+type IAuditService interface {
+	RecordRequest(ctx context.Context, scope *AuditScope, request RequestAudit)
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	Verify(ctx context.Context) (*domain.AuditVerification, error)
+}
+
+type IAPIUsageService interface {
+	Record(ctx context.Context, version string)
+	GetUsage(ctx context.Context, versions []string, days int) ([]domain.APIUsage, error)
+}
+
+type IIdempotencyService interface {
+	Begin(ctx context.Context, scope, key, fingerprint string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, record *domain.IdempotencyRecord)
+	Abandon(ctx context.Context, scope, key string)
+	Forget(ctx context.Context, userID uuid.UUID) error
 }
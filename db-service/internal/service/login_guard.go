@@ -0,0 +1,232 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/cache"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	loginFailuresPrefix = "login:failures:"
+	loginBackoffPrefix  = "login:backoff:"
+	loginLockPrefix     = "login:lock:"
+)
+
+type LoginGuardConfig struct {
+	MaxUserAttempts int
+	MaxIPAttempts   int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+	LockoutDuration time.Duration
+	AttemptWindow   time.Duration
+}
+
+type LoginThrottledError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account temporarily locked, retry after %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+type LoginFailure struct {
+	Attempts    int64
+	UserLocked  bool
+	IPLocked    bool
+	LockedUntil time.Time
+}
+
+// ILoginGuard throttles logins. Every Check that lets an attempt through
+// counts it, and must be followed by RegisterFailure when the attempt fails
+// or by Release when the checked factor was correct.
+type ILoginGuard interface {
+	Check(ctx context.Context, username, ip string) error
+	RegisterFailure(ctx context.Context, username, ip string) (*LoginFailure, error)
+	Release(ctx context.Context, username, ip string)
+	RegisterSuccess(ctx context.Context, username string)
+	Unlock(ctx context.Context, username string) error
+}
+
+type LoginGuard struct {
+	redisClient cache.IRedisClient
+	config      LoginGuardConfig
+}
+
+func NewLoginGuard(redisClient cache.IRedisClient, config LoginGuardConfig) ILoginGuard {
+	return &LoginGuard{
+		redisClient: redisClient,
+		config:      config,
+	}
+}
+
+func userSubject(username string) string {
+	return "user:" + username
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
+
+func loginSubjects(username, ip string) []string {
+	subjects := []string{userSubject(username)}
+	if ip != "" {
+		subjects = append(subjects, ipSubject(ip))
+	}
+	return subjects
+}
+
+// Check rejects a login attempt while either the username or the client IP is
+// locked out or still inside its backoff window. Otherwise it counts the
+// attempt before it is made, so concurrent attempts cannot all slip through
+// before the first of them failed.
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	subjects := loginSubjects(username, ip)
+
+	for _, subject := range subjects {
+		if ttl, ok := g.activeTTL(ctx, loginLockPrefix+subject); ok {
+			return &LoginThrottledError{Locked: true, RetryAfter: ttl}
+		}
+		if ttl, ok := g.activeTTL(ctx, loginBackoffPrefix+subject); ok {
+			return &LoginThrottledError{RetryAfter: ttl}
+		}
+	}
+
+	for i, subject := range subjects {
+		attempts, err := g.redisClient.IncrByExpire(ctx, loginFailuresPrefix+subject, 1, g.config.AttemptWindow)
+		if err != nil {
+			return fmt.Errorf("error counting login attempt for %s: %w", subject, err)
+		}
+		if attempts > int64(g.maxAttempts(subject)) {
+			// The attempts already counted decide on the lockout.
+			g.release(ctx, subjects[:i+1])
+			return &LoginThrottledError{RetryAfter: g.config.BaseBackoff}
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure locks or delays the subjects of an attempt that Check has
+// counted and that turned out to be wrong.
+func (g *LoginGuard) RegisterFailure(ctx context.Context, username, ip string) (*LoginFailure, error) {
+	attempts, locked, err := g.registerSubjectFailure(ctx, userSubject(username))
+	if err != nil {
+		return nil, err
+	}
+
+	failure := &LoginFailure{
+		Attempts:   attempts,
+		UserLocked: locked,
+	}
+
+	if ip != "" {
+		_, ipLocked, err := g.registerSubjectFailure(ctx, ipSubject(ip))
+		if err != nil {
+			return nil, err
+		}
+		failure.IPLocked = ipLocked
+	}
+
+	if failure.UserLocked || failure.IPLocked {
+		failure.LockedUntil = time.Now().Add(g.config.LockoutDuration)
+	}
+
+	return failure, nil
+}
+
+// Release gives back the attempt counted by Check once its factor was
+// correct, so successful logins do not use up the budget of a shared IP.
+func (g *LoginGuard) Release(ctx context.Context, username, ip string) {
+	g.release(ctx, loginSubjects(username, ip))
+}
+
+func (g *LoginGuard) release(ctx context.Context, subjects []string) {
+	for _, subject := range subjects {
+		if _, err := g.redisClient.IncrByExpire(ctx, loginFailuresPrefix+subject, -1, g.config.AttemptWindow); err != nil {
+			log.Printf("Error releasing login attempt of %s: %v", subject, err)
+		}
+	}
+}
+
+func (g *LoginGuard) RegisterSuccess(ctx context.Context, username string) {
+	subject := userSubject(username)
+	for _, key := range []string{loginFailuresPrefix + subject, loginBackoffPrefix + subject} {
+		if err := g.redisClient.Delete(ctx, key); err != nil {
+			log.Printf("Error resetting login counter %s: %v", key, err)
+		}
+	}
+}
+
+func (g *LoginGuard) Unlock(ctx context.Context, username string) error {
+	subject := userSubject(username)
+	for _, key := range []string{loginFailuresPrefix + subject, loginBackoffPrefix + subject, loginLockPrefix + subject} {
+		if err := g.redisClient.Delete(ctx, key); err != nil {
+			return fmt.Errorf("error unlocking %s: %w", username, err)
+		}
+	}
+	return nil
+}
+
+func (g *LoginGuard) maxAttempts(subject string) int {
+	if strings.HasPrefix(subject, "ip:") {
+		return g.config.MaxIPAttempts
+	}
+	return g.config.MaxUserAttempts
+}
+
+func (g *LoginGuard) registerSubjectFailure(ctx context.Context, subject string) (int64, bool, error) {
+	failuresKey := loginFailuresPrefix + subject
+
+	// Check already counted the attempt, so this reads the count it left.
+	attempts, err := g.redisClient.IncrByExpire(ctx, failuresKey, 0, g.config.AttemptWindow)
+	if err != nil {
+		return 0, false, fmt.Errorf("error counting failed login for %s: %w", subject, err)
+	}
+
+	if attempts >= int64(g.maxAttempts(subject)) {
+		if err := g.redisClient.Set(ctx, loginLockPrefix+subject, "1", g.config.LockoutDuration); err != nil {
+			return 0, false, fmt.Errorf("error locking %s: %w", subject, err)
+		}
+		g.redisClient.Delete(ctx, failuresKey)
+		return attempts, true, nil
+	}
+
+	if err := g.redisClient.Set(ctx, loginBackoffPrefix+subject, "1", g.backoff(attempts)); err != nil {
+		return 0, false, fmt.Errorf("error setting login backoff for %s: %w", subject, err)
+	}
+
+	return attempts, false, nil
+}
+
+func (g *LoginGuard) backoff(attempts int64) time.Duration {
+	delay := g.config.BaseBackoff
+	for i := int64(1); i < attempts && delay < g.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > g.config.MaxBackoff {
+		delay = g.config.MaxBackoff
+	}
+	return delay
+}
+
+// activeTTL reports how long the key lives on. A single TTL call tells a
+// missing key (-2) from one without expiry (-1), which is treated as the base
+// backoff.
+func (g *LoginGuard) activeTTL(ctx context.Context, key string) (time.Duration, bool) {
+	ttl, err := g.redisClient.TTL(ctx, key)
+	if err != nil {
+		log.Printf("Error reading login guard key %s: %v", key, err)
+		return 0, false
+	}
+	if ttl == -1 {
+		return g.config.BaseBackoff, true
+	}
+	return ttl, ttl > 0
+}
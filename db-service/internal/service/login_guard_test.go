@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryRedis is an in-memory IRedisClient with expiring keys.
+type memoryRedis struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{values: map[string]string{}, expires: map[string]time.Time{}}
+}
+
+// live drops the key once it expired. The caller holds the lock.
+func (m *memoryRedis) live(key string) (string, bool) {
+	if expires, ok := m.expires[key]; ok && !time.Now().Before(expires) {
+		delete(m.values, key)
+		delete(m.expires, key)
+	}
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *memoryRedis) setLocked(key, value string, expiration time.Duration) {
+	m.values[key] = value
+	delete(m.expires, key)
+	if expiration > 0 {
+		m.expires[key] = time.Now().Add(expiration)
+	}
+}
+
+func (m *memoryRedis) Close() error {
+	return nil
+}
+
+func (m *memoryRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setLocked(key, toString(value), expiration)
+	return nil
+}
+
+func (m *memoryRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.live(key); ok {
+		return false, nil
+	}
+	m.setLocked(key, toString(value), expiration)
+	return true, nil
+}
+
+func (m *memoryRedis) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.live(key)
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (m *memoryRedis) GetDel(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.live(key)
+	if !ok {
+		return "", redis.Nil
+	}
+	delete(m.values, key)
+	delete(m.expires, key)
+	return value, nil
+}
+
+func (m *memoryRedis) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.values, key)
+		delete(m.expires, key)
+	}
+	return nil
+}
+
+func (m *memoryRedis) Incr(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.incrLocked(key, 1)
+}
+
+func (m *memoryRedis) IncrByExpire(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, err := m.incrLocked(key, delta)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := m.expires[key]; !ok {
+		m.expires[key] = time.Now().Add(expiration)
+	}
+	return value, nil
+}
+
+func (m *memoryRedis) incrLocked(key string, delta int64) (int64, error) {
+	current, _ := m.live(key)
+	value := int64(0)
+	if current != "" {
+		var err error
+		if value, err = strconv.ParseInt(current, 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	value += delta
+	m.values[key] = strconv.FormatInt(value, 10)
+	return value, nil
+}
+
+func (m *memoryRedis) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.live(key); ok {
+		m.expires[key] = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+// TTL answers like Redis: -2 for a missing key and -1 for one without expiry.
+func (m *memoryRedis) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.live(key); !ok {
+		return -2, nil
+	}
+	expires, ok := m.expires[key]
+	if !ok {
+		return -1, nil
+	}
+	return time.Until(expires), nil
+}
+
+func (m *memoryRedis) Scan(ctx context.Context, match string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.values {
+		if _, ok := m.live(key); !ok {
+			continue
+		}
+		if matched, _ := path.Match(match, key); matched {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	panic("memoryRedis: unsupported value type")
+}
+
+var testLoginGuardConfig = LoginGuardConfig{
+	MaxUserAttempts: 3,
+	MaxIPAttempts:   5,
+	BaseBackoff:     time.Second,
+	MaxBackoff:      4 * time.Second,
+	LockoutDuration: time.Minute,
+	AttemptWindow:   time.Minute,
+}
+
+func TestLoginGuardBackoff(t *testing.T) {
+	guard := &LoginGuard{config: testLoginGuardConfig}
+
+	tests := []struct {
+		attempts int64
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 4 * time.Second},
+		{60, 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := guard.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// failLogin runs one attempt that fails, the way the user service does.
+func failLogin(t *testing.T, guard ILoginGuard, username, ip string) (*LoginFailure, error) {
+	t.Helper()
+
+	if err := guard.Check(context.Background(), username, ip); err != nil {
+		return nil, err
+	}
+	failure, err := guard.RegisterFailure(context.Background(), username, ip)
+	if err != nil {
+		t.Fatalf("error registering failure: %v", err)
+	}
+	return failure, nil
+}
+
+// clearBackoff lets the next attempt through without waiting for the delay.
+func clearBackoff(redisClient *memoryRedis, subjects ...string) {
+	for _, subject := range subjects {
+		redisClient.Delete(context.Background(), loginBackoffPrefix+subject)
+	}
+}
+
+func TestLoginGuardLocksUserAfterMaxAttempts(t *testing.T) {
+	redisClient := newMemoryRedis()
+	guard := NewLoginGuard(redisClient, testLoginGuardConfig)
+
+	tests := []struct {
+		attempt    int64
+		wantLocked bool
+	}{
+		{1, false},
+		{2, false},
+		{3, true},
+	}
+
+	for _, tt := range tests {
+		failure, err := failLogin(t, guard, "anna", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("attempt %d was throttled: %v", tt.attempt, err)
+		}
+		if failure.Attempts != tt.attempt || failure.UserLocked != tt.wantLocked || failure.IPLocked {
+			t.Errorf("attempt %d: failure = %+v, want %d attempts and locked %t", tt.attempt, failure, tt.attempt, tt.wantLocked)
+		}
+		clearBackoff(redisClient, userSubject("anna"), ipSubject("10.0.0.1"))
+	}
+
+	var throttled *LoginThrottledError
+	err := guard.Check(context.Background(), "anna", "10.0.0.2")
+	if !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("Check after lockout = %v, want a lock", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > testLoginGuardConfig.LockoutDuration {
+		t.Errorf("RetryAfter = %s, want up to %s", throttled.RetryAfter, testLoginGuardConfig.LockoutDuration)
+	}
+
+	if err := guard.Check(context.Background(), "bert", "10.0.0.1"); err != nil {
+		t.Errorf("other user from the same IP was throttled: %v", err)
+	}
+
+	if err := guard.Unlock(context.Background(), "anna"); err != nil {
+		t.Fatalf("error unlocking: %v", err)
+	}
+	if err := guard.Check(context.Background(), "anna", "10.0.0.2"); err != nil {
+		t.Errorf("Check after unlock = %v, want nil", err)
+	}
+}
+
+func TestLoginGuardBacksOffAfterFailure(t *testing.T) {
+	redisClient := newMemoryRedis()
+	guard := NewLoginGuard(redisClient, testLoginGuardConfig)
+
+	if _, err := failLogin(t, guard, "anna", ""); err != nil {
+		t.Fatalf("first attempt was throttled: %v", err)
+	}
+
+	var throttled *LoginThrottledError
+	err := guard.Check(context.Background(), "anna", "")
+	if !errors.As(err, &throttled) || throttled.Locked {
+		t.Fatalf("Check inside backoff = %v, want a backoff", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > testLoginGuardConfig.BaseBackoff {
+		t.Errorf("RetryAfter = %s, want up to %s", throttled.RetryAfter, testLoginGuardConfig.BaseBackoff)
+	}
+
+	ttl, _ := redisClient.TTL(context.Background(), loginFailuresPrefix+userSubject("anna"))
+	if ttl <= 0 || ttl > testLoginGuardConfig.AttemptWindow {
+		t.Errorf("failure counter TTL = %s, want it to expire within %s", ttl, testLoginGuardConfig.AttemptWindow)
+	}
+}
+
+func TestLoginGuardLocksIP(t *testing.T) {
+	redisClient := newMemoryRedis()
+	guard := NewLoginGuard(redisClient, testLoginGuardConfig)
+	ip := "10.0.0.1"
+
+	var failure *LoginFailure
+	for i := 0; i < testLoginGuardConfig.MaxIPAttempts; i++ {
+		username := "user" + strconv.Itoa(i)
+		var err error
+		if failure, err = failLogin(t, guard, username, ip); err != nil {
+			t.Fatalf("attempt %d was throttled: %v", i+1, err)
+		}
+		clearBackoff(redisClient, userSubject(username), ipSubject(ip))
+	}
+	if !failure.IPLocked || failure.UserLocked {
+		t.Errorf("last failure = %+v, want only the IP locked", failure)
+	}
+
+	var throttled *LoginThrottledError
+	if err := guard.Check(context.Background(), "fresh", ip); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Errorf("Check from locked IP = %v, want a lock", err)
+	}
+	if err := guard.Check(context.Background(), "fresh", "10.0.0.2"); err != nil {
+		t.Errorf("Check from other IP = %v, want nil", err)
+	}
+}
+
+func TestLoginGuardCountsConcurrentAttempts(t *testing.T) {
+	guard := NewLoginGuard(newMemoryRedis(), testLoginGuardConfig)
+
+	var wg sync.WaitGroup
+	var passed int32
+	var mu sync.Mutex
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := guard.Check(context.Background(), "anna", ""); err == nil {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if passed != int32(testLoginGuardConfig.MaxUserAttempts) {
+		t.Errorf("%d concurrent attempts passed Check, want %d", passed, testLoginGuardConfig.MaxUserAttempts)
+	}
+}
+
+func TestLoginGuardReleaseAndSuccess(t *testing.T) {
+	redisClient := newMemoryRedis()
+	guard := NewLoginGuard(redisClient, testLoginGuardConfig)
+	ctx := context.Background()
+	ip := "10.0.0.1"
+
+	for i := 0; i < 2*testLoginGuardConfig.MaxIPAttempts; i++ {
+		if err := guard.Check(ctx, "anna", ip); err != nil {
+			t.Fatalf("successful login %d was throttled: %v", i+1, err)
+		}
+		guard.Release(ctx, "anna", ip)
+		guard.RegisterSuccess(ctx, "anna")
+	}
+
+	if _, err := failLogin(t, guard, "anna", ip); err != nil {
+		t.Fatalf("attempt was throttled: %v", err)
+	}
+	guard.RegisterSuccess(ctx, "anna")
+
+	for _, key := range []string{loginFailuresPrefix + userSubject("anna"), loginBackoffPrefix + userSubject("anna")} {
+		if _, err := redisClient.Get(ctx, key); err != redis.Nil {
+			t.Errorf("%s survived a successful login", key)
+		}
+	}
+	if count, _ := redisClient.Get(ctx, loginFailuresPrefix+ipSubject(ip)); count != "1" {
+		t.Errorf("IP failures = %q, want the failed attempt to stay counted", count)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/kafka"
+	"awesomeProject22/db-service/internal/mail"
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/requestctx"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+const loginHistoryLimit = 50
+
+type LoginMonitorConfig struct {
+	NotifySuspicious bool
+}
+
+type LoginMonitorServiceImpl struct {
+	historyRepo   repository.ILoginHistoryRepository
+	authzService  IAuthzService
+	securityAudit repository.ISecurityAuditRepository
+	eventProducer kafka.IEventProducer
+	mailSender    mail.IMailSender
+	config        LoginMonitorConfig
+}
+
+func LoginMonitorService(historyRepo repository.ILoginHistoryRepository, authzService IAuthzService,
+	securityAudit repository.ISecurityAuditRepository, eventProducer kafka.IEventProducer, mailSender mail.IMailSender,
+	config LoginMonitorConfig) ILoginMonitorService {
+	return &LoginMonitorServiceImpl{
+		historyRepo:   historyRepo,
+		authzService:  authzService,
+		securityAudit: securityAudit,
+		eventProducer: eventProducer,
+		mailSender:    mailSender,
+		config:        config,
+	}
+}
+
+// RecordLogin compares the login with the devices and networks the user signed
+// in from before. The very first login only establishes that baseline. Storage
+// failures are logged and never block the login itself.
+func (s *LoginMonitorServiceImpl) RecordLogin(ctx context.Context, user *domain.User) *domain.LoginRecord {
+	info := requestctx.ClientInfoFromContext(ctx)
+	record := &domain.LoginRecord{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		IP:          info.IP,
+		Network:     ipNetwork(info.IP),
+		UserAgent:   info.UserAgent,
+		Fingerprint: deviceFingerprint(info),
+		Reasons:     []string{},
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	reasons, err := s.detectAnomalies(ctx, record)
+	if err != nil {
+		log.Printf("Error checking login of user %s against known devices: %v", user.ID, err)
+	} else {
+		record.Reasons = reasons
+		record.Suspicious = len(reasons) > 0
+	}
+
+	if err := s.historyRepo.Record(ctx, record); err != nil {
+		log.Printf("Error storing login history of user %s: %v", user.ID, err)
+	}
+
+	if record.Suspicious {
+		s.reportSuspicious(ctx, user, record)
+	}
+
+	return record
+}
+
+func (s *LoginMonitorServiceImpl) detectAnomalies(ctx context.Context, record *domain.LoginRecord) ([]string, error) {
+	devices, err := s.historyRepo.GetDevices(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return []string{}, nil
+	}
+
+	reasons := []string{}
+
+	known := false
+	for _, device := range devices {
+		if device.Fingerprint == record.Fingerprint {
+			known = true
+			break
+		}
+	}
+	if !known {
+		reasons = append(reasons, domain.LoginReasonNewDevice)
+	}
+
+	if record.Network != "" {
+		seen, err := s.historyRepo.HasSeenNetwork(ctx, record.UserID, record.Network)
+		if err != nil {
+			return nil, err
+		}
+		if !seen {
+			reasons = append(reasons, domain.LoginReasonNewNetwork)
+		}
+	}
+
+	return reasons, nil
+}
+
+func (s *LoginMonitorServiceImpl) reportSuspicious(ctx context.Context, user *domain.User, record *domain.LoginRecord) {
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventSuspiciousLogin, user.ID, map[string]interface{}{
+		"ip":          record.IP,
+		"network":     record.Network,
+		"user_agent":  record.UserAgent,
+		"fingerprint": record.Fingerprint,
+		"reasons":     record.Reasons,
+	})
+
+	if err := s.eventProducer.PublishSuspiciousLogin(ctx, user.Username, record); err != nil {
+		log.Printf("Error publishing suspicious login event: %v", err)
+	} else {
+		log.Printf("Suspicious login event published: %s (%s)", user.Username, user.ID)
+	}
+
+	if !s.config.NotifySuspicious || s.mailSender == nil || user.Email == "" {
+		return
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "New sign-in to your account",
+		Body: fmt.Sprintf("Hello %s,\n\nYour account was signed in to at %s from a device or network we have not seen before.\n\nIP address: %s\nBrowser: %s\n\nIf this was not you, change your password right away.\n",
+			user.Username, record.CreatedAt.Format(time.RFC1123), record.IP, record.UserAgent),
+	}
+	if err := s.mailSender.Send(ctx, msg); err != nil {
+		log.Printf("Error sending suspicious login mail to user %s: %v", user.ID, err)
+	}
+}
+
+func (s *LoginMonitorServiceImpl) GetDevices(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error) {
+	if err := requireOwner(ctx, s.authzService, userID, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	devices, err := s.historyRepo.GetDevices(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting known devices: %w", err)
+	}
+	return devices, nil
+}
+
+func (s *LoginMonitorServiceImpl) GetHistory(ctx context.Context, userID uuid.UUID) ([]domain.LoginRecord, error) {
+	if err := requireOwner(ctx, s.authzService, userID, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	records, err := s.historyRepo.GetHistory(ctx, userID, loginHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting login history: %w", err)
+	}
+	return records, nil
+}
+
+// deviceFingerprint prefers the id a client sends in X-Device-ID and falls
+// back to the browser signature.
+func deviceFingerprint(info requestctx.ClientInfo) string {
+	source := "device:" + info.DeviceID
+	if info.DeviceID == "" {
+		source = "agent:" + info.UserAgent + "|" + info.AcceptLanguage
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// ipNetwork approximates the login location by its network: /24 for IPv4 and
+// /48 for IPv6.
+func ipNetwork(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/cache"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/oidc"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	oidcStatePrefix = "oidc:state:"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+var usernameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+type oidcLoginState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+type OIDCServiceImpl struct {
+	providers    map[string]oidc.IProvider
+	redisClient  cache.IRedisClient
+	identityRepo repository.IIdentityRepository
+	userRepo     repository.IUserRepository
+	userService  IUserService
+	authzService IAuthzService
+	twoFactor    ITwoFactorService
+}
+
+func OIDCService(providers []oidc.IProvider, redisClient cache.IRedisClient, identityRepo repository.IIdentityRepository,
+	userRepo repository.IUserRepository, userService IUserService, authzService IAuthzService, twoFactor ITwoFactorService) IOIDCService {
+	byName := make(map[string]oidc.IProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Config().Name] = provider
+	}
+
+	return &OIDCServiceImpl{
+		providers:    byName,
+		redisClient:  redisClient,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		authzService: authzService,
+		twoFactor:    twoFactor,
+	}
+}
+
+// BeginLogin stores the PKCE verifier and nonce server-side, keyed by the
+// state value, and returns the provider's authorization URL.
+func (s *OIDCServiceImpl) BeginLogin(ctx context.Context, providerName string) (string, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+
+	loginState, err := json.Marshal(oidcLoginState{
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error serializing login state: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, oidcStatePrefix+state, string(loginState), oidcStateTTL); err != nil {
+		return "", fmt.Errorf("error storing login state: %w", err)
+	}
+
+	return provider.AuthCodeURL(ctx, state, nonce, verifier)
+}
+
+// CompleteLogin redeems the authorization code and signs in the linked user.
+// Like a password login the result is a challenge rather than a session when
+// the account needs a second factor.
+func (s *OIDCServiceImpl) CompleteLogin(ctx context.Context, providerName, state, code string) (*AuthResult, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState, err := s.consumeState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if loginState.Provider != providerName {
+		return nil, ErrTokenInvalid
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, loginState.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	user, err := s.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	s.applyRoleMapping(ctx, provider.Config(), user, claims)
+
+	return s.userService.CompleteExternalLogin(ctx, user.ID)
+}
+
+func (s *OIDCServiceImpl) provider(name string) (oidc.IProvider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: identity provider %s is not configured", ErrNotFound, name)
+	}
+	return provider, nil
+}
+
+func (s *OIDCServiceImpl) consumeState(ctx context.Context, state string) (*oidcLoginState, error) {
+	if state == "" {
+		return nil, ErrTokenInvalid
+	}
+
+	// Reading and deleting at once lets a state be redeemed only once, even by
+	// concurrent callbacks.
+	stored, err := s.redisClient.GetDel(ctx, oidcStatePrefix+state)
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("error reading login state: %w", err)
+	}
+
+	var loginState oidcLoginState
+	if err := json.Unmarshal([]byte(stored), &loginState); err != nil {
+		return nil, fmt.Errorf("error deserializing login state: %w", err)
+	}
+	return &loginState, nil
+}
+
+// resolveUser finds the account linked to the external identity. Unlinked
+// identities are linked to an existing account only when the provider vouches
+// for the email address and the account is neither protected by a second
+// factor nor privileged; otherwise a new account is provisioned.
+func (s *OIDCServiceImpl) resolveUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (*domain.User, error) {
+	identity, err := s.identityRepo.Get(ctx, providerName, claims.Subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLogin(ctx, providerName, claims.Subject, claims.Email); err != nil {
+			log.Printf("Error updating identity login time: %v", err)
+		}
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting linked user: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("error looking up identity: %w", err)
+	}
+
+	var user *domain.User
+	if claims.Email != "" {
+		existing, err := s.userRepo.GetByEmail(ctx, claims.Email)
+		switch {
+		case err == nil && claims.EmailVerified:
+			if err := s.checkAutoLink(ctx, existing); err != nil {
+				return nil, err
+			}
+			user = existing
+		case err == nil:
+			return nil, fmt.Errorf("%w: an account with email %s already exists and the provider has not verified it",
+				ErrForbidden, claims.Email)
+		case !errors.Is(err, repository.ErrNotFound):
+			return nil, fmt.Errorf("error looking up user by email: %w", err)
+		}
+	}
+
+	if user == nil {
+		user, err = s.provisionUser(ctx, providerName, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identityRepo.Link(ctx, &domain.UserIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		UserID:   user.ID,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error linking identity: %w", err)
+	}
+
+	log.Printf("Identity %s/%s linked to user %s (%s)", providerName, claims.Subject, user.Username, user.ID)
+	return user, nil
+}
+
+// checkAutoLink refuses to link an identity to an account that a verified
+// email address alone must not unlock: one with two-factor authentication,
+// administrator rights or any role beyond the default member role.
+func (s *OIDCServiceImpl) checkAutoLink(ctx context.Context, user *domain.User) error {
+	refused := fmt.Errorf("%w: the account with email %s cannot be linked to an identity provider automatically",
+		ErrForbidden, user.Email)
+
+	if user.IsAdmin {
+		return refused
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error checking two-factor authentication: %w", err)
+	}
+	if enabled {
+		return refused
+	}
+
+	roles, err := s.authzService.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting roles of user %s: %w", user.ID, err)
+	}
+	for _, role := range roles {
+		if role.Name != domain.RoleMember {
+			return refused
+		}
+	}
+	return nil
+}
+
+func (s *OIDCServiceImpl) provisionUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (*domain.User, error) {
+	if claims.Email == "" {
+		return nil, fmt.Errorf("%w: identity provider did not supply an email address", ErrInvalidInput)
+	}
+
+	username, err := s.availableUsername(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Provisioned accounts get an unguessable password; they sign in through
+	// the provider or set a password via the reset flow.
+	password, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		Username:      username,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+	if err := s.userService.Create(ctx, user, password); err != nil {
+		return nil, fmt.Errorf("error provisioning user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *OIDCServiceImpl) availableUsername(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameSanitizer.ReplaceAllString(base, "")
+	if base == "" {
+		base = providerName + "-user"
+	}
+
+	candidates := []string{base, base + "-" + providerName}
+	for _, candidate := range candidates {
+		_, err := s.userRepo.GetByUsername(ctx, candidate)
+		if errors.Is(err, repository.ErrNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error checking username availability: %w", err)
+		}
+	}
+
+	suffix, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + strings.ToLower(suffix[:6]), nil
+}
+
+// applyRoleMapping keeps the roles granted through the provider in line with
+// the role claim, revoking those the claim no longer carries.
+func (s *OIDCServiceImpl) applyRoleMapping(ctx context.Context, config oidc.ProviderConfig, user *domain.User, claims *oidc.IDTokenClaims) {
+	if config.RoleClaim == "" {
+		return
+	}
+
+	var roles []string
+	for _, value := range claims.StringValues(config.RoleClaim) {
+		if role, ok := config.RoleMapping[value]; ok {
+			roles = append(roles, role)
+		}
+	}
+
+	if err := s.authzService.SyncProviderRoles(ctx, user.ID, config.Name, roles); err != nil {
+		log.Printf("Error mapping claim %s to roles for user %s: %v", config.RoleClaim, user.ID, err)
+	}
+}
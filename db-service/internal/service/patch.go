@@ -0,0 +1,53 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/jsonpatch"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// bookDocument is the representation of a book that clients may patch.
+type bookDocument struct {
+	Genre  string `json:"genre"`
+	Name   string `json:"name"`
+	Author string `json:"author"`
+	Year   int    `json:"year"`
+}
+
+// userDocument is the representation of a user that clients may patch. The
+// password is write-only and therefore never part of the original document.
+type userDocument struct {
+	Username string `json:"username" validate:"required,min=3,max=64"`
+	Email    string `json:"email" validate:"required,max=255,email"`
+	IsAdmin  bool   `json:"is_admin"`
+	Password string `json:"password,omitempty" validate:"omitempty,min=8,max=128"`
+}
+
+// applyPatch patches the JSON form of original and strictly decodes the result
+// into patched, which must point to a zero value so removed members stay
+// removed.
+func applyPatch(original interface{}, mediaType string, patch []byte, patched interface{}) error {
+	document, err := json.Marshal(original)
+	if err != nil {
+		return fmt.Errorf("error encoding document to patch: %w", err)
+	}
+
+	result, err := jsonpatch.Apply(mediaType, document, patch)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrInvalidPatch) || errors.Is(err, jsonpatch.ErrTestFailed) ||
+			errors.Is(err, jsonpatch.ErrUnsupportedMediaType) {
+			return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		return fmt.Errorf("error applying patch: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		return fmt.Errorf("%w: patched document is invalid: %v", ErrInvalidInput, err)
+	}
+
+	return Validate(patched)
+}
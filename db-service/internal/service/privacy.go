@@ -0,0 +1,149 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/kafka"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+type PrivacyServiceImpl struct {
+	userRepo      repository.IUserRepository
+	privacyRepo   repository.IPrivacyRepository
+	loginHistory  repository.ILoginHistoryRepository
+	twoFactorRepo repository.ITwoFactorRepository
+	authzService  IAuthzService
+	securityAudit repository.ISecurityAuditRepository
+	loginGuard    ILoginGuard
+	idempotency   IIdempotencyService
+	eventProducer kafka.IEventProducer
+}
+
+func PrivacyService(userRepo repository.IUserRepository, privacyRepo repository.IPrivacyRepository,
+	loginHistory repository.ILoginHistoryRepository, twoFactorRepo repository.ITwoFactorRepository, authzService IAuthzService, securityAudit repository.ISecurityAuditRepository,
+	loginGuard ILoginGuard, idempotency IIdempotencyService, eventProducer kafka.IEventProducer) IPrivacyService {
+	return &PrivacyServiceImpl{
+		userRepo:      userRepo,
+		privacyRepo:   privacyRepo,
+		loginHistory:  loginHistory,
+		twoFactorRepo: twoFactorRepo,
+		authzService:  authzService,
+		securityAudit: securityAudit,
+		loginGuard:    loginGuard,
+		idempotency:   idempotency,
+		eventProducer: eventProducer,
+	}
+}
+
+func (s *PrivacyServiceImpl) Export(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error) {
+	if err := requireOwner(ctx, s.authzService, userID, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: user %s", ErrNotFound, userID)
+		}
+		return nil, fmt.Errorf("error getting user for export: %w", err)
+	}
+
+	export := &domain.DataExport{
+		User:        *user,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	if export.Roles, err = s.authzService.GetUserRoles(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Loans, err = s.privacyRepo.GetLoans(ctx, userID); err != nil {
+		return nil, fmt.Errorf("error exporting loans: %w", err)
+	}
+	if export.SecurityEvents, err = s.privacyRepo.GetSecurityEvents(ctx, userID); err != nil {
+		return nil, fmt.Errorf("error exporting security events: %w", err)
+	}
+	if export.Identities, err = s.privacyRepo.GetIdentities(ctx, userID); err != nil {
+		return nil, fmt.Errorf("error exporting linked identities: %w", err)
+	}
+	if export.LoginHistory, err = s.loginHistory.GetHistory(ctx, userID, 0); err != nil {
+		return nil, fmt.Errorf("error exporting login history: %w", err)
+	}
+	if export.Devices, err = s.loginHistory.GetDevices(ctx, userID); err != nil {
+		return nil, fmt.Errorf("error exporting known devices: %w", err)
+	}
+
+	twoFactor, err := s.twoFactorRepo.GetByUser(ctx, userID)
+	switch {
+	case err == nil:
+		export.TwoFactor = twoFactor
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, fmt.Errorf("error exporting two-factor settings: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventDataExported, userID, nil)
+
+	log.Printf("Personal data of user %s exported", userID)
+	return export, nil
+}
+
+// Erase anonymizes the account in Postgres and revokes its API keys; tokens
+// issued before are refused by EnsureActive from then on. It drops every Redis
+// entry keyed by the old username or email or holding a stored response about
+// the user, and tells downstream consumers to do the same.
+//
+// What remains is pseudonymous: the account ID in loans, security events and
+// the audit log, which the library keeps to account for changes and security
+// incidents. The audit log never held personal field values, but keeps the IP
+// address of requests, as it is append-only by design.
+func (s *PrivacyServiceImpl) Erase(ctx context.Context, userID uuid.UUID) error {
+	if err := forbidDuringImpersonation(ctx, "erasing an account"); err != nil {
+		return err
+	}
+
+	if err := requireOwner(ctx, s.authzService, userID, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: user %s", ErrNotFound, userID)
+		}
+		return fmt.Errorf("error getting user for erasure: %w", err)
+	}
+
+	if err := s.userRepo.Anonymize(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: user %s is already erased", ErrNotFound, userID)
+		}
+		return fmt.Errorf("error erasing user: %w", err)
+	}
+	erasedAt := time.Now().UTC()
+
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Unlock(ctx, user.Username); err != nil {
+			log.Printf("Error purging login throttling state of user %s: %v", userID, err)
+		}
+	}
+	if s.idempotency != nil {
+		if err := s.idempotency.Forget(ctx, userID); err != nil {
+			log.Printf("Error purging stored idempotent responses of user %s: %v", userID, err)
+		}
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventUserErased, userID, nil)
+	recordChange(ctx, "user.erased", domain.AuditEntityUser, userID, nil, nil)
+
+	if err := s.eventProducer.PublishUserErased(ctx, userID, erasedAt); err != nil {
+		log.Printf("Error publishing user erasure event: %v", err)
+	} else {
+		log.Printf("User erasure event published: %s", userID)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"github.com/google/uuid"
+	"log"
+)
+
+// recordSecurityEvent never fails the calling operation: a lost audit row is
+// logged loudly instead of rolling back a change that already happened.
+func recordSecurityEvent(ctx context.Context, repo repository.ISecurityAuditRepository, eventType string, targetUserID uuid.UUID, details map[string]interface{}) {
+	event := &domain.SecurityEvent{
+		EventType:    eventType,
+		TargetUserID: &targetUserID,
+		Details:      details,
+	}
+
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && !principal.IsAPIKey() {
+		actorID := principal.ActorID()
+		event.ActorID = &actorID
+		if principal.IsImpersonated() {
+			event.Details = withDetail(event.Details, "impersonated_user_id", principal.UserID.String())
+		}
+	} else if ok {
+		event.Details = withDetail(event.Details, "api_key_id", principal.APIKeyID.String())
+	}
+
+	if err := repo.Record(ctx, event); err != nil {
+		log.Printf("Error recording security event %s for user %s: %v", eventType, targetUserID, err)
+	}
+}
+
+func withDetail(details map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details[key] = value
+	return details
+}
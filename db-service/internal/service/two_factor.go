@@ -0,0 +1,272 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/requestctx"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorConfig struct {
+	Issuer           string
+	RequireForAdmins bool
+	ChallengeTTL     time.Duration
+}
+
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorServiceImpl struct {
+	repo          repository.ITwoFactorRepository
+	userRepo      repository.IUserRepository
+	authzService  IAuthzService
+	securityAudit repository.ISecurityAuditRepository
+	loginGuard    ILoginGuard
+	config        TwoFactorConfig
+}
+
+func TwoFactorService(repo repository.ITwoFactorRepository, userRepo repository.IUserRepository, authzService IAuthzService,
+	securityAudit repository.ISecurityAuditRepository, loginGuard ILoginGuard, config TwoFactorConfig) ITwoFactorService {
+	return &TwoFactorServiceImpl{
+		repo:          repo,
+		userRepo:      userRepo,
+		authzService:  authzService,
+		securityAudit: securityAudit,
+		loginGuard:    loginGuard,
+		config:        config,
+	}
+}
+
+func (s *TwoFactorServiceImpl) Enroll(ctx context.Context, userID uuid.UUID) (*Enrollment, error) {
+	if err := forbidDuringImpersonation(ctx, "two-factor enrollment"); err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", ErrInvalidInput)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user to enroll not found: %w", err)
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SavePending(ctx, userID, secret); err != nil {
+		return nil, fmt.Errorf("error starting two-factor enrollment: %w", err)
+	}
+
+	return &Enrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.config.Issuer, user.Username, secret),
+	}, nil
+}
+
+func (s *TwoFactorServiceImpl) Activate(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := forbidDuringImpersonation(ctx, "two-factor activation"); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: two-factor enrollment has not been started", ErrInvalidInput)
+		}
+		return nil, fmt.Errorf("error getting two-factor settings: %w", err)
+	}
+	if twoFactor.Enabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", ErrInvalidInput)
+	}
+
+	step, ok := auth.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Enable(ctx, userID, step); err != nil {
+		return nil, fmt.Errorf("error activating two-factor authentication: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventTwoFactorEnabled, userID, nil)
+
+	log.Printf("Two-factor authentication enabled for user %s", userID)
+	return codes, nil
+}
+
+func (s *TwoFactorServiceImpl) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := forbidDuringImpersonation(ctx, "disabling two-factor authentication"); err != nil {
+		return err
+	}
+
+	if s.config.RequireForAdmins {
+		isAdmin, err := s.authzService.HasPermission(ctx, userID, domain.PermissionRolesManage)
+		if err != nil {
+			return fmt.Errorf("error checking administrator rights: %w", err)
+		}
+		if isAdmin {
+			return fmt.Errorf("%w: two-factor authentication is required for administrators", ErrForbidden)
+		}
+	}
+
+	if err := s.verifyThrottled(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("error disabling two-factor authentication: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventTwoFactorDisabled, userID, nil)
+
+	log.Printf("Two-factor authentication disabled for user %s", userID)
+	return nil
+}
+
+func (s *TwoFactorServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := forbidDuringImpersonation(ctx, "regenerating recovery codes"); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyThrottled(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventRecoveryCodesRegenerated, userID, nil)
+
+	log.Printf("Recovery codes regenerated for user %s", userID)
+	return codes, nil
+}
+
+func (s *TwoFactorServiceImpl) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	twoFactor, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error getting two-factor settings: %w", err)
+	}
+	return twoFactor.Enabled, nil
+}
+
+// Verify accepts either a current TOTP code or one of the unused recovery
+// codes. Recovery codes are single use.
+func (s *TwoFactorServiceImpl) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	twoFactor, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("error getting two-factor settings: %w", err)
+	}
+	if !twoFactor.Enabled {
+		return ErrInvalidCredentials
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := auth.ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		fresh, err := s.repo.MarkStepUsed(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidCredentials
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventRecoveryCodeUsed, userID, nil)
+	return nil
+}
+
+// verifyThrottled runs Verify under the login guard, so that a stolen session
+// cannot be used to guess codes faster than a login could.
+func (s *TwoFactorServiceImpl) verifyThrottled(ctx context.Context, userID uuid.UUID, code string) error {
+	if s.loginGuard == nil {
+		return s.Verify(ctx, userID, code)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error getting user to verify: %w", err)
+	}
+
+	ip := requestctx.ClientInfoFromContext(ctx).IP
+	if err := s.loginGuard.Check(ctx, user.Username, ip); err != nil {
+		return err
+	}
+
+	err = s.Verify(ctx, userID, code)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		s.loginGuard.Release(ctx, user.Username, ip)
+		return err
+	}
+
+	if _, guardErr := s.loginGuard.RegisterFailure(ctx, user.Username, ip); guardErr != nil {
+		log.Printf("Error registering failed two-factor code for %s: %v", user.Username, guardErr)
+	}
+	return err
+}
+
+func (s *TwoFactorServiceImpl) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("error generating recovery code: %w", err)
+		}
+		code := strings.ToLower(encoding.EncodeToString(raw))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("error storing recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
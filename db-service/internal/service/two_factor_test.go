@@ -0,0 +1,86 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/domain"
+	"awesomeProject22/db-service/internal/repository"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+)
+
+// memoryTwoFactorRepo keeps one enabled second factor with its recovery codes.
+type memoryTwoFactorRepo struct {
+	repository.ITwoFactorRepository
+	twoFactor     *domain.TwoFactor
+	recoveryCodes map[string]bool
+}
+
+func (r *memoryTwoFactorRepo) GetByUser(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	copied := *r.twoFactor
+	return &copied, nil
+}
+
+func (r *memoryTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	r.recoveryCodes = map[string]bool{}
+	for _, hash := range codeHashes {
+		r.recoveryCodes[hash] = true
+	}
+	return nil
+}
+
+func (r *memoryTwoFactorRepo) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	if !r.recoveryCodes[codeHash] {
+		return false, nil
+	}
+	delete(r.recoveryCodes, codeHash)
+	return true, nil
+}
+
+func newTestTwoFactorService(t *testing.T) (*TwoFactorServiceImpl, *memorySecurityAudit, uuid.UUID) {
+	t.Helper()
+
+	user := &domain.User{ID: uuid.New(), Username: "anna"}
+	users := &memoryUserRepo{users: map[uuid.UUID]*domain.User{user.ID: user}}
+	repo := &memoryTwoFactorRepo{
+		twoFactor:     &domain.TwoFactor{UserID: user.ID, Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Enabled: true},
+		recoveryCodes: map[string]bool{hashToken("aaaaabbbbb"): true},
+	}
+	audit := &memorySecurityAudit{}
+	guard := NewLoginGuard(newMemoryRedis(), testLoginGuardConfig)
+	twoFactor := TwoFactorService(repo, users, AuthzService(newMemoryRoleRepo(users), users, audit), audit, guard,
+		TwoFactorConfig{})
+	return twoFactor.(*TwoFactorServiceImpl), audit, user.ID
+}
+
+func TestRegenerateRecoveryCodesThrottlesWrongCodes(t *testing.T) {
+	twoFactor, _, userID := newTestTwoFactorService(t)
+	ctx := context.Background()
+
+	if _, err := twoFactor.RegenerateRecoveryCodes(ctx, userID, "000000"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("first wrong code: error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	var throttled *LoginThrottledError
+	if _, err := twoFactor.RegenerateRecoveryCodes(ctx, userID, "aaaaa-bbbbb"); !errors.As(err, &throttled) {
+		t.Fatalf("retry during backoff: error = %v, want LoginThrottledError", err)
+	}
+	if err := twoFactor.Disable(ctx, userID, "aaaaa-bbbbb"); !errors.As(err, &throttled) {
+		t.Fatalf("disable during backoff: error = %v, want LoginThrottledError", err)
+	}
+}
+
+func TestRegenerateRecoveryCodesRecordsEvent(t *testing.T) {
+	twoFactor, audit, userID := newTestTwoFactorService(t)
+
+	codes, err := twoFactor.RegenerateRecoveryCodes(context.Background(), userID, "aaaaa-bbbbb")
+	if err != nil {
+		t.Fatalf("error regenerating recovery codes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	if !audit.has(domain.SecurityEventRecoveryCodesRegenerated) {
+		t.Errorf("no %s security event was recorded", domain.SecurityEventRecoveryCodesRegenerated)
+	}
+}
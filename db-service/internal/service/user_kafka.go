@@ -1,30 +1,66 @@
 package service
 
 import (
+	"awesomeProject22/db-service/internal/auth"
 	"awesomeProject22/db-service/internal/domain"
 	"awesomeProject22/db-service/internal/kafka"
 	"awesomeProject22/db-service/internal/repository"
+	"awesomeProject22/db-service/internal/requestctx"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"log"
+	"unicode/utf8"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 128
 )
 
 type UserServiceImpl struct {
 	repo          repository.IUserRepository
 	eventProducer kafka.IEventProducer
+	tokenManager  auth.ITokenManager
+	hasher        auth.IPasswordHasher
+	authzService  IAuthzService
+	securityAudit repository.ISecurityAuditRepository
+	loginGuard    ILoginGuard
+	twoFactor     ITwoFactorService
+	twoFactorCfg  TwoFactorConfig
+	loginMonitor  ILoginMonitorService
 }
 
-func UserService(repo repository.IUserRepository, eventProducer kafka.IEventProducer) IUserService {
+type AuthResult struct {
+	Token              string `json:"token,omitempty"`
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty"`
+	EnrollmentRequired bool   `json:"two_factor_enrollment_required,omitempty"`
+	ChallengeToken     string `json:"challenge_token,omitempty"`
+}
+
+func UserService(repo repository.IUserRepository, eventProducer kafka.IEventProducer, tokenManager auth.ITokenManager,
+	hasher auth.IPasswordHasher, authzService IAuthzService, securityAudit repository.ISecurityAuditRepository, loginGuard ILoginGuard,
+	twoFactor ITwoFactorService, twoFactorCfg TwoFactorConfig, loginMonitor ILoginMonitorService) IUserService {
 	return &UserServiceImpl{
 		repo:          repo,
 		eventProducer: eventProducer,
+		tokenManager:  tokenManager,
+		hasher:        hasher,
+		authzService:  authzService,
+		securityAudit: securityAudit,
+		loginGuard:    loginGuard,
+		twoFactor:     twoFactor,
+		twoFactorCfg:  twoFactorCfg,
+		loginMonitor:  loginMonitor,
 	}
 }
 
 func (s *UserServiceImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if err := requireOwner(ctx, s.authzService, id, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("error getting user by ID: %w", err)
@@ -32,20 +68,66 @@ func (s *UserServiceImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	return user, nil
 }
 
+// GetByIDs applies the rule of GetByID to a batch: callers read their own
+// account and need users:read for any other. Missing users are left out.
+func (s *UserServiceImpl) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	if err := requireOwnerOfAll(ctx, s.authzService, ids, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting users by ID: %w", err)
+	}
+	return users, nil
+}
+
+func (s *UserServiceImpl) GetLoans(ctx context.Context, userIDs []uuid.UUID) ([]domain.UserBook, error) {
+	if err := requireOwnerOfAll(ctx, s.authzService, userIDs, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	loans, err := s.repo.GetLoans(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting loans of users: %w", err)
+	}
+	return loans, nil
+}
+
 func (s *UserServiceImpl) Create(ctx context.Context, user *domain.User, password string) error {
 	if user.ID == uuid.Nil {
 		user.ID = uuid.New()
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if user.IsAdmin {
+		if err := s.authorizePrivilegeChange(ctx, user.ID, false, true); err != nil {
+			return err
+		}
+	}
+
+	if err := checkPassword(password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return fmt.Errorf("error hashing password: %w", err)
 	}
 
-	user.PasswordHash = string(hashedPassword)
+	user.PasswordHash = hashedPassword
 
 	if err := s.repo.Create(ctx, user); err != nil {
-		return fmt.Errorf("error creating user: %w", err)
+		return fmt.Errorf("error creating user: %w", userConflict(err))
+	}
+
+	recordChange(ctx, "user.created", domain.AuditEntityUser, user.ID, nil, user)
+
+	if user.IsAdmin {
+		recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventPrivilegeChanged, user.ID, map[string]interface{}{
+			"field": "is_admin",
+			"old":   false,
+			"new":   true,
+		})
 	}
 
 	if err := s.eventProducer.PublishUserCreated(ctx, user); err != nil {
@@ -58,46 +140,152 @@ func (s *UserServiceImpl) Create(ctx context.Context, user *domain.User, passwor
 }
 
 func (s *UserServiceImpl) Update(ctx context.Context, user *domain.User, passwordChanged bool, newPassword string) error {
+	if err := requireOwner(ctx, s.authzService, user.ID, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
 
 	currentUser, err := s.repo.GetByID(ctx, user.ID)
 	if err != nil {
 		return fmt.Errorf("user not found for update: %w", err)
 	}
 
+	if err := s.update(ctx, currentUser, user, passwordChanged, newPassword); err != nil {
+		return err
+	}
+
+	if err := s.eventProducer.PublishUserUpdated(ctx, user); err != nil {
+		log.Printf("Error publishing user update event: %v", err)
+	} else {
+		log.Printf("User update event published: %s (%s)", user.Username, user.ID)
+	}
+
+	return nil
+}
+
+// Patch applies a merge patch or JSON patch to the user. A "password" member
+// sets a new password. Consumers of user.updated get the full user as after a
+// PUT; user.patched additionally lists only the fields that changed.
+func (s *UserServiceImpl) Patch(ctx context.Context, id uuid.UUID, version int64, mediaType string, patch []byte) (*domain.User, error) {
+	if err := requireOwner(ctx, s.authzService, id, domain.PermissionUsersWrite); err != nil {
+		return nil, err
+	}
+
+	currentUser, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user to patch not found: %w", err)
+	}
+	if err := checkVersion(currentUser.Version, version); err != nil {
+		return nil, err
+	}
+
+	original := userDocument{Username: currentUser.Username, Email: currentUser.Email, IsAdmin: currentUser.IsAdmin}
+	var patched userDocument
+	if err := applyPatch(original, mediaType, patch, &patched); err != nil {
+		return nil, err
+	}
+
+	user := *currentUser
+	user.Username = patched.Username
+	user.Email = patched.Email
+	user.IsAdmin = patched.IsAdmin
+
+	passwordChanged := patched.Password != ""
+	if user == *currentUser && !passwordChanged {
+		return currentUser, nil
+	}
+
+	if err := s.update(ctx, currentUser, &user, passwordChanged, patched.Password); err != nil {
+		return nil, err
+	}
+
+	if err := s.eventProducer.PublishUserUpdated(ctx, &user); err != nil {
+		log.Printf("Error publishing user update event: %v", err)
+	}
+	if err := s.eventProducer.PublishUserPatched(ctx, currentUser, &user); err != nil {
+		log.Printf("Error publishing user patch event: %v", err)
+	} else {
+		log.Printf("User patch event published: %s (%s)", user.Username, user.ID)
+	}
+
+	return &user, nil
+}
+
+func (s *UserServiceImpl) update(ctx context.Context, currentUser, user *domain.User, passwordChanged bool, newPassword string) error {
+	if loginDataChanged(currentUser, user, passwordChanged) {
+		if err := forbidDuringImpersonation(ctx, "changing password, username or email"); err != nil {
+			return err
+		}
+	}
+
+	if currentUser.Email != user.Email {
+		user.EmailVerified = false
+	}
+
+	privilegeChanged := currentUser.IsAdmin != user.IsAdmin
+	if privilegeChanged {
+		if err := s.authorizePrivilegeChange(ctx, user.ID, currentUser.IsAdmin, user.IsAdmin); err != nil {
+			return err
+		}
+	}
+
 	if passwordChanged {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		if err := checkPassword(newPassword); err != nil {
+			return err
+		}
+
+		hashedPassword, err := s.hasher.Hash(newPassword)
 		if err != nil {
 			return fmt.Errorf("error hashing password: %w", err)
 		}
-		user.PasswordHash = string(hashedPassword)
-	} else {
-		user.PasswordHash = currentUser.PasswordHash
+
+		if err := s.repo.UpdateWithPassword(ctx, user, hashedPassword); err != nil {
+			return fmt.Errorf("error updating user and password: %w", userConflict(err))
+		}
+	} else if err := s.repo.Update(ctx, user); err != nil {
+		return fmt.Errorf("error updating user: %w", userConflict(err))
 	}
 
-	if err := s.repo.Update(ctx, user); err != nil {
-		return fmt.Errorf("error updating user: %w", err)
+	recordChange(ctx, "user.updated", domain.AuditEntityUser, user.ID, currentUser, user)
+	if passwordChanged {
+		recordChange(ctx, "user.password_changed", domain.AuditEntityUser, user.ID, nil, nil)
 	}
 
-	if err := s.eventProducer.PublishUserUpdated(ctx, user); err != nil {
-		log.Printf("Error publishing user update event: %v", err)
-	} else {
-		log.Printf("User update event published: %s (%s)", user.Username, user.ID)
+	if privilegeChanged {
+		recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventPrivilegeChanged, user.ID, map[string]interface{}{
+			"field": "is_admin",
+			"old":   currentUser.IsAdmin,
+			"new":   user.IsAdmin,
+		})
 	}
 
 	return nil
 }
 
-func (s *UserServiceImpl) Delete(ctx context.Context, id uuid.UUID) error {
+// loginDataChanged reports a change of what the user signs in or recovers the
+// account with, which an impersonator must not make.
+func loginDataChanged(currentUser, user *domain.User, passwordChanged bool) bool {
+	return passwordChanged || currentUser.Username != user.Username || currentUser.Email != user.Email
+}
+
+func (s *UserServiceImpl) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	if err := forbidDuringImpersonation(ctx, "deleting an account"); err != nil {
+		return err
+	}
+	if err := requireOwner(ctx, s.authzService, id, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
 
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("user to delete not found: %w", err)
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
+	if err := s.repo.Delete(ctx, id, version); err != nil {
 		return fmt.Errorf("error deleting user: %w", err)
 	}
 
+	recordChange(ctx, "user.deleted", domain.AuditEntityUser, id, user, nil)
+
 	if err := s.eventProducer.PublishUserDeleted(ctx, id); err != nil {
 		log.Printf("Error publishing user deletion event: %v", err)
 	} else {
@@ -107,23 +295,290 @@ func (s *UserServiceImpl) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (s *UserServiceImpl) Authenticate(ctx context.Context, username, password string) (string, error) {
-	user, err := s.repo.GetByUsername(ctx, username)
+// Authenticate checks the password and either issues an access token or, for
+// accounts protected by a second factor, a short-lived challenge token that
+// must be redeemed through CompleteTwoFactorLogin.
+func (s *UserServiceImpl) Authenticate(ctx context.Context, username, password string) (*AuthResult, error) {
+	ip := requestctx.ClientInfoFromContext(ctx).IP
+
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Check(ctx, username, ip); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.repo.GetCredentials(ctx, username)
+	if err != nil {
+		s.registerLoginFailure(ctx, username, ip)
+		return nil, ErrInvalidCredentials
+	}
+
+	valid, err := s.hasher.Verify(user.PasswordHash, password)
+	if err != nil {
+		log.Printf("Error verifying password of user %s: %v", user.ID, err)
+	}
+	if !valid {
+		s.registerLoginFailure(ctx, username, ip)
+		return nil, ErrInvalidCredentials
+	}
+	s.releaseLoginAttempt(ctx, username, ip)
+
+	s.upgradePasswordHash(ctx, user, password)
+
+	return s.secondFactorStep(ctx, user)
+}
+
+// secondFactorStep finishes a login whose first factor was verified. Users
+// with two-factor authentication get a challenge instead of a session, and
+// administrators without it must enroll first when that is required.
+func (s *UserServiceImpl) secondFactorStep(ctx context.Context, user *domain.User) (*AuthResult, error) {
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		challenge, err := s.tokenManager.IssueChallenge(user.ID, auth.PurposeTwoFactorLogin, s.twoFactorCfg.ChallengeTTL)
+		if err != nil {
+			return nil, fmt.Errorf("error issuing two-factor challenge: %w", err)
+		}
+		return &AuthResult{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	if s.twoFactorCfg.RequireForAdmins {
+		isAdmin, err := s.authzService.HasPermission(ctx, user.ID, domain.PermissionRolesManage)
+		if err != nil {
+			return nil, fmt.Errorf("error checking administrator rights: %w", err)
+		}
+		if isAdmin {
+			challenge, err := s.tokenManager.IssueChallenge(user.ID, auth.PurposeTwoFactorEnroll, s.twoFactorCfg.ChallengeTTL)
+			if err != nil {
+				return nil, fmt.Errorf("error issuing two-factor enrollment challenge: %w", err)
+			}
+			return &AuthResult{EnrollmentRequired: true, ChallengeToken: challenge}, nil
+		}
+	}
+
+	token, err := s.completeLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResult{Token: token}, nil
+}
+
+// upgradePasswordHash re-hashes the password with the current algorithm and
+// cost while the plain value is at hand. A failure only delays the upgrade.
+func (s *UserServiceImpl) upgradePasswordHash(ctx context.Context, user *domain.User, password string) {
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password of user %s: %v", user.ID, err)
+		return
+	}
+
+	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("Error storing upgraded password hash of user %s: %v", user.ID, err)
+		return
+	}
+
+	user.PasswordHash = hashedPassword
+	log.Printf("Password hash of user %s upgraded", user.ID)
+}
+
+func (s *UserServiceImpl) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string) (string, error) {
+	user, err := s.resolveChallenge(ctx, challengeToken, auth.PurposeTwoFactorLogin)
+	if err != nil {
+		return "", err
+	}
+
+	ip := requestctx.ClientInfoFromContext(ctx).IP
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Check(ctx, user.Username, ip); err != nil {
+			return "", err
+		}
+	}
+
+	if err := s.twoFactor.Verify(ctx, user.ID, code); err != nil {
+		s.registerLoginFailure(ctx, user.Username, ip)
+		return "", err
+	}
+	s.releaseLoginAttempt(ctx, user.Username, ip)
+
+	return s.completeLogin(ctx, user)
+}
+
+func (s *UserServiceImpl) StartTwoFactorEnrollment(ctx context.Context, challengeToken string) (*Enrollment, error) {
+	user, err := s.resolveChallenge(ctx, challengeToken, auth.PurposeTwoFactorEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.twoFactor.Enroll(ctx, user.ID)
+}
+
+func (s *UserServiceImpl) CompleteTwoFactorEnrollment(ctx context.Context, challengeToken, code string) (string, []string, error) {
+	user, err := s.resolveChallenge(ctx, challengeToken, auth.PurposeTwoFactorEnroll)
+	if err != nil {
+		return "", nil, err
+	}
+
+	recoveryCodes, err := s.twoFactor.Activate(ctx, user.ID, code)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := s.completeLogin(ctx, user)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, recoveryCodes, nil
+}
+
+// CompleteExternalLogin continues the login of a user that was authenticated
+// by an external identity provider. The provider only replaces the password,
+// so the second factor is asked for as in Authenticate.
+func (s *UserServiceImpl) CompleteExternalLogin(ctx context.Context, id uuid.UUID) (*AuthResult, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user for external login: %w", err)
+	}
+	return s.secondFactorStep(ctx, user)
+}
+
+// checkPassword enforces the password policy wherever a password is set, so
+// that no endpoint can store one the others would refuse.
+func checkPassword(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength || length > maxPasswordLength {
+		return fmt.Errorf("%w: password must be between %d and %d characters long", ErrInvalidInput,
+			minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+// userConflict names the value that is already taken rather than the
+// database constraint.
+func userConflict(err error) error {
+	var conflict *repository.ConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+
+	switch conflict.Constraint {
+	case "users_username_key":
+		return fmt.Errorf("%w: username is already taken", ErrConflict)
+	case "users_email_key":
+		return fmt.Errorf("%w: email is already registered", ErrConflict)
+	default:
+		return fmt.Errorf("%w: user already exists", ErrConflict)
+	}
+}
+
+func (s *UserServiceImpl) resolveChallenge(ctx context.Context, challengeToken, purpose string) (*domain.User, error) {
+	claims, err := s.tokenManager.Parse(challengeToken)
+	if err != nil || claims.Purpose != purpose {
+		return nil, ErrTokenInvalid
+	}
+
+	user, err := s.repo.GetByID(ctx, claims.Subject)
 	if err != nil {
-		return "", errors.New("Invalid credentials")
+		return nil, ErrTokenInvalid
+	}
+	return user, nil
+}
+
+func (s *UserServiceImpl) completeLogin(ctx context.Context, user *domain.User) (string, error) {
+	if s.loginGuard != nil {
+		s.loginGuard.RegisterSuccess(ctx, user.Username)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("Invalid credentials")
+	token, err := s.tokenManager.Issue(user.ID)
+	if err != nil {
+		return "", fmt.Errorf("error issuing token: %w", err)
 	}
 
-	if err := s.eventProducer.PublishUserLoggedIn(ctx, user.ID, user.Username); err != nil {
+	record := s.loginMonitor.RecordLogin(ctx, user)
+
+	if err := s.eventProducer.PublishUserLoggedIn(ctx, user.Username, record); err != nil {
 		log.Printf("Error publishing user login event: %v", err)
 	} else {
 		log.Printf("User login event published: %s (%s)", user.Username, user.ID)
 	}
 
-	return user.ID.String(), nil
+	return token, nil
+}
+
+func (s *UserServiceImpl) releaseLoginAttempt(ctx context.Context, username, ip string) {
+	if s.loginGuard != nil {
+		s.loginGuard.Release(ctx, username, ip)
+	}
+}
+
+func (s *UserServiceImpl) registerLoginFailure(ctx context.Context, username, ip string) {
+	if s.loginGuard == nil {
+		return
+	}
+
+	failure, err := s.loginGuard.RegisterFailure(ctx, username, ip)
+	if err != nil {
+		log.Printf("Error registering failed login for %s: %v", username, err)
+		return
+	}
+
+	if err := s.eventProducer.PublishUserLoginFailed(ctx, username, ip, failure.Attempts); err != nil {
+		log.Printf("Error publishing failed login event: %v", err)
+	}
+
+	if failure.UserLocked || failure.IPLocked {
+		if err := s.eventProducer.PublishUserLocked(ctx, username, ip, failure.LockedUntil); err != nil {
+			log.Printf("Error publishing account lock event: %v", err)
+		} else {
+			log.Printf("Login locked for %s from %s until %s", username, ip, failure.LockedUntil)
+		}
+	}
+}
+
+func (s *UserServiceImpl) Unlock(ctx context.Context, id uuid.UUID) error {
+	if s.loginGuard == nil {
+		return nil
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("user to unlock not found: %w", err)
+	}
+
+	if err := s.loginGuard.Unlock(ctx, user.Username); err != nil {
+		return fmt.Errorf("error unlocking user: %w", err)
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventAccountUnlocked, user.ID, nil)
+	recordChange(ctx, "user.unlocked", domain.AuditEntityUser, user.ID, nil, nil)
+
+	log.Printf("User %s (%s) unlocked", user.Username, user.ID)
+	return nil
+}
+
+// authorizePrivilegeChange enforces that privilege fields are only set by an
+// authenticated administrator. Denied attempts are recorded as well.
+func (s *UserServiceImpl) authorizePrivilegeChange(ctx context.Context, targetID uuid.UUID, oldValue, newValue bool) error {
+	allowed, err := s.authzService.Authorize(ctx, domain.PermissionRolesManage)
+	if err != nil {
+		return fmt.Errorf("error checking privilege change permission: %w", err)
+	}
+	if allowed {
+		return nil
+	}
+
+	recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventPrivilegeDenied, targetID, map[string]interface{}{
+		"field": "is_admin",
+		"old":   oldValue,
+		"new":   newValue,
+	})
+
+	return fmt.Errorf("%w: only administrators may change privilege fields", ErrForbidden)
 }
 
 func (s *UserServiceImpl) IsAdmin(ctx context.Context, id uuid.UUID) (bool, error) {
@@ -135,6 +590,22 @@ func (s *UserServiceImpl) IsAdmin(ctx context.Context, id uuid.UUID) (bool, erro
 	return user.IsAdmin, nil
 }
 
+// EnsureActive refuses the tokens of accounts that were erased or deleted
+// after the token was issued.
+func (s *UserServiceImpl) EnsureActive(ctx context.Context, id uuid.UUID) error {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTokenInvalid
+		}
+		return fmt.Errorf("error checking account %s: %w", id, err)
+	}
+	if user.ErasedAt != nil {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
 func (s *UserServiceImpl) GetAll(ctx context.Context) ([]domain.User, error) {
 	users, err := s.repo.GetAll(ctx)
 	if err != nil {
@@ -142,3 +613,171 @@ func (s *UserServiceImpl) GetAll(ctx context.Context) ([]domain.User, error) {
 	}
 	return users, nil
 }
+
+// GetPage returns one page of users and the number of all users.
+func (s *UserServiceImpl) GetPage(ctx context.Context, limit, offset int) ([]domain.User, int, error) {
+	users, total, err := s.repo.GetPage(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting page of users: %w", err)
+	}
+	return users, total, nil
+}
+
+// ApplyBatch creates, updates and deletes users in one transaction under the
+// same rules as the single requests. Audit records, security events and
+// events only follow once every item has been written.
+func (s *UserServiceImpl) ApplyBatch(ctx context.Context, operations []UserBatchOperation) ([]BatchResult, error) {
+	if err := checkBatchSize(len(operations)); err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.UserBatchItem, len(operations))
+	seen := map[uuid.UUID]bool{}
+	for i, operation := range operations {
+		item, err := s.prepareBatchItem(ctx, operation, seen)
+		if err != nil {
+			return nil, batchItemError(i, err)
+		}
+		items[i] = item
+	}
+
+	if err := s.repo.ApplyBatch(ctx, items); err != nil {
+		var itemErr *BatchItemError
+		if errors.As(err, &itemErr) {
+			itemErr.Err = userConflict(itemErr.Err)
+		}
+		return nil, fmt.Errorf("error applying user batch: %w", err)
+	}
+
+	results := make([]BatchResult, len(items))
+	for i, item := range items {
+		results[i] = BatchResult{Op: item.Op, ID: item.User.ID, Version: item.User.Version}
+		s.publishBatchItem(ctx, item)
+	}
+	log.Printf("User batch applied: %d items", len(items))
+
+	return results, nil
+}
+
+func (s *UserServiceImpl) prepareBatchItem(ctx context.Context, operation UserBatchOperation, seen map[uuid.UUID]bool) (domain.UserBatchItem, error) {
+	item := domain.UserBatchItem{Op: operation.Op}
+
+	switch operation.Op {
+	case domain.BatchCreate:
+		user := &domain.User{ID: uuid.New(), Username: operation.Username, Email: operation.Email}
+		if operation.IsAdmin != nil && *operation.IsAdmin {
+			if err := s.authorizePrivilegeChange(ctx, user.ID, false, true); err != nil {
+				return item, err
+			}
+			user.IsAdmin = true
+		}
+
+		if err := checkPassword(operation.Password); err != nil {
+			return item, err
+		}
+		hashedPassword, err := s.hasher.Hash(operation.Password)
+		if err != nil {
+			return item, fmt.Errorf("error hashing password: %w", err)
+		}
+		user.PasswordHash = hashedPassword
+
+		item.User = user
+		return item, nil
+	case domain.BatchUpdate:
+	case domain.BatchDelete:
+		if err := forbidDuringImpersonation(ctx, "deleting an account"); err != nil {
+			return item, err
+		}
+	default:
+		return item, fmt.Errorf("%w: unknown operation %q", ErrInvalidInput, operation.Op)
+	}
+
+	if err := checkBatchTarget(operation.ID, seen); err != nil {
+		return item, err
+	}
+	if err := requireOwner(ctx, s.authzService, operation.ID, domain.PermissionUsersWrite); err != nil {
+		return item, err
+	}
+
+	currentUser, err := s.repo.GetByID(ctx, operation.ID)
+	if err != nil {
+		return item, fmt.Errorf("user to %s not found: %w", operation.Op, err)
+	}
+	if err := checkVersion(currentUser.Version, operation.Version); err != nil {
+		return item, err
+	}
+	item.Current = currentUser
+
+	// The stored version pins the write to the user read here.
+	user := *currentUser
+	item.User = &user
+	if operation.Op == domain.BatchDelete {
+		return item, nil
+	}
+
+	user.Username = operation.Username
+	user.Email = operation.Email
+	if operation.IsAdmin != nil {
+		user.IsAdmin = *operation.IsAdmin
+	}
+
+	if loginDataChanged(currentUser, &user, operation.Password != "") {
+		if err := forbidDuringImpersonation(ctx, "changing password, username or email"); err != nil {
+			return item, err
+		}
+	}
+	if currentUser.Email != user.Email {
+		user.EmailVerified = false
+	}
+	if currentUser.IsAdmin != user.IsAdmin {
+		if err := s.authorizePrivilegeChange(ctx, user.ID, currentUser.IsAdmin, user.IsAdmin); err != nil {
+			return item, err
+		}
+	}
+
+	if operation.Password != "" {
+		if err := checkPassword(operation.Password); err != nil {
+			return item, err
+		}
+		hashedPassword, err := s.hasher.Hash(operation.Password)
+		if err != nil {
+			return item, fmt.Errorf("error hashing password: %w", err)
+		}
+		user.PasswordHash = hashedPassword
+		item.PasswordChanged = true
+	}
+
+	return item, nil
+}
+
+func (s *UserServiceImpl) publishBatchItem(ctx context.Context, item domain.UserBatchItem) {
+	user := item.User
+	wasAdmin := item.Current != nil && item.Current.IsAdmin
+
+	var err error
+	switch item.Op {
+	case domain.BatchCreate:
+		recordChange(ctx, "user.created", domain.AuditEntityUser, user.ID, nil, user)
+		err = s.eventProducer.PublishUserCreated(ctx, user)
+	case domain.BatchUpdate:
+		recordChange(ctx, "user.updated", domain.AuditEntityUser, user.ID, item.Current, user)
+		if item.PasswordChanged {
+			recordChange(ctx, "user.password_changed", domain.AuditEntityUser, user.ID, nil, nil)
+		}
+		err = s.eventProducer.PublishUserUpdated(ctx, user)
+	case domain.BatchDelete:
+		recordChange(ctx, "user.deleted", domain.AuditEntityUser, user.ID, item.Current, nil)
+		err = s.eventProducer.PublishUserDeleted(ctx, user.ID)
+	}
+	if err != nil {
+		log.Printf("Error publishing user %s event for %s: %v", item.Op, user.ID, err)
+	}
+
+	if item.Op != domain.BatchDelete && user.IsAdmin != wasAdmin {
+		recordSecurityEvent(ctx, s.securityAudit, domain.SecurityEventPrivilegeChanged, user.ID, map[string]interface{}{
+			"field": "is_admin",
+			"old":   wasAdmin,
+			"new":   user.IsAdmin,
+		})
+	}
+}
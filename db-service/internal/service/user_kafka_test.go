@@ -0,0 +1,121 @@
+package service
+
+import (
+	"awesomeProject22/db-service/internal/auth"
+	"awesomeProject22/db-service/internal/domain"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestPrepareBatchItemPasswordChanged(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Username: "anna", Email: "anna@example.com", PasswordHash: "stored", Version: 1}
+	users := &memoryUserRepo{users: map[uuid.UUID]*domain.User{user.ID: user}}
+	s := &UserServiceImpl{
+		repo:         users,
+		hasher:       prefixHasher{},
+		authzService: AuthzService(newMemoryRoleRepo(users), users, &memorySecurityAudit{}),
+	}
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: user.ID})
+
+	tests := []struct {
+		name        string
+		password    string
+		wantChanged bool
+		wantHash    string
+	}{
+		{"profile only", "", false, "stored"},
+		{"new password", "correct horse", true, "hashed:correct horse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := s.prepareBatchItem(ctx, UserBatchOperation{
+				Op: domain.BatchUpdate, ID: user.ID, Username: "anna2", Email: user.Email, Password: tt.password,
+			}, map[uuid.UUID]bool{})
+			if err != nil {
+				t.Fatalf("error preparing batch item: %v", err)
+			}
+			if item.PasswordChanged != tt.wantChanged {
+				t.Errorf("PasswordChanged = %t, want %t", item.PasswordChanged, tt.wantChanged)
+			}
+			if item.User.PasswordHash != tt.wantHash {
+				t.Errorf("PasswordHash = %q, want %q", item.User.PasswordHash, tt.wantHash)
+			}
+		})
+	}
+}
+
+// writeRecordingUserRepo notes which write stored the update.
+type writeRecordingUserRepo struct {
+	*memoryUserRepo
+	writes []string
+}
+
+func (r *writeRecordingUserRepo) Update(ctx context.Context, user *domain.User) error {
+	r.writes = append(r.writes, "update")
+	return nil
+}
+
+func (r *writeRecordingUserRepo) UpdateWithPassword(ctx context.Context, user *domain.User, passwordHash string) error {
+	r.writes = append(r.writes, "update with "+passwordHash)
+	return nil
+}
+
+func TestUpdateWritesPasswordWithProfile(t *testing.T) {
+	current := &domain.User{ID: uuid.New(), Username: "anna", Email: "anna@example.com", Version: 1}
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: current.ID})
+
+	tests := []struct {
+		name            string
+		passwordChanged bool
+		password        string
+		wantWrite       string
+	}{
+		{"profile only", false, "", "update"},
+		{"new password", true, "correct horse", "update with hashed:correct horse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &writeRecordingUserRepo{memoryUserRepo: &memoryUserRepo{users: map[uuid.UUID]*domain.User{current.ID: current}}}
+			s := &UserServiceImpl{repo: users, hasher: prefixHasher{}}
+
+			updated := *current
+			updated.Username = "anna2"
+			if err := s.update(ctx, current, &updated, tt.passwordChanged, tt.password); err != nil {
+				t.Fatalf("error updating user: %v", err)
+			}
+			if len(users.writes) != 1 || users.writes[0] != tt.wantWrite {
+				t.Errorf("writes = %q, want one %q", users.writes, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestEnsureActive(t *testing.T) {
+	erasedAt := time.Now()
+	active := &domain.User{ID: uuid.New(), Username: "anna"}
+	erased := &domain.User{ID: uuid.New(), Username: "erased", ErasedAt: &erasedAt}
+	s := &UserServiceImpl{repo: &memoryUserRepo{users: map[uuid.UUID]*domain.User{active.ID: active, erased.ID: erased}}}
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		wantErr error
+	}{
+		{"active", active.ID, nil},
+		{"erased", erased.ID, ErrTokenInvalid},
+		{"deleted", uuid.New(), ErrTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.EnsureActive(context.Background(), tt.id); !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnsureActive error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MinYear is the earliest publication year a book may have.
+const MinYear = 1
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Struct checks the `validate` tags of a struct (or a pointer to one) and
+// returns every violation, named after the JSON field. Rules are comma
+// separated:
+//
+//	required   the value must not be empty
+//	omitempty  skip the remaining rules when the value is empty
+//	min=N      minimum length of strings and slices, minimum value of numbers
+//	max=N      maximum length of strings and slices, maximum value of numbers
+//	email      a bare e-mail address
+//	year       a publication year between MinYear and next year
+func Struct(v interface{}) []FieldError {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fieldErrors []FieldError
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		if message := checkField(value.Field(i), strings.Split(tag, ",")); message != "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: jsonName(field), Message: message})
+		}
+	}
+	return fieldErrors
+}
+
+// checkField returns the message of the first rule the value breaks.
+func checkField(value reflect.Value, rules []string) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if containsRule(rules, "required") {
+				return "is required"
+			}
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	empty := value.IsZero()
+	if value.Kind() == reflect.String {
+		empty = strings.TrimSpace(value.String()) == ""
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch name {
+		case "required":
+			if empty {
+				return "is required"
+			}
+		case "omitempty":
+			if empty {
+				return ""
+			}
+		case "min", "max":
+			if message := checkBound(value, name, arg); message != "" {
+				return message
+			}
+		case "email":
+			address, err := mail.ParseAddress(value.String())
+			if err != nil || address.Address != value.String() {
+				return "must be a valid email address"
+			}
+		case "year":
+			maxYear := time.Now().Year() + 1
+			if year := value.Int(); year < MinYear || year > int64(maxYear) {
+				return fmt.Sprintf("must be between %d and %d", MinYear, maxYear)
+			}
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q", name))
+		}
+	}
+	return ""
+}
+
+func checkBound(value reflect.Value, rule, arg string) string {
+	limit, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid %s bound %q", rule, arg))
+	}
+
+	var actual int64
+	minFormat, maxFormat := "must be at least %d", "must be at most %d"
+	switch value.Kind() {
+	case reflect.String:
+		actual = int64(utf8.RuneCountInString(value.String()))
+		minFormat, maxFormat = "must be at least %d characters long", "must be at most %d characters long"
+	case reflect.Slice, reflect.Map:
+		actual = int64(value.Len())
+		minFormat, maxFormat = "must contain at least %d items", "must contain at most %d items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = value.Int()
+	default:
+		panic(fmt.Sprintf("validation: %s is not supported for %s", rule, value.Kind()))
+	}
+
+	if rule == "min" && actual < limit {
+		return fmt.Sprintf(minFormat, limit)
+	}
+	if rule == "max" && actual > limit {
+		return fmt.Sprintf(maxFormat, limit)
+	}
+	return ""
+}
+
+func containsRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
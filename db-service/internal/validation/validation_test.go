@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRequest struct {
+	Name     string   `json:"name" validate:"required,min=2,max=5"`
+	Email    string   `json:"email,omitempty" validate:"omitempty,email"`
+	Year     int      `json:"year" validate:"year"`
+	Count    int      `json:"count" validate:"min=1,max=10"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Nickname *string  `json:"nickname" validate:"omitempty,min=3"`
+	Comment  *string  `json:"comment" validate:"required"`
+	Untagged string   `validate:"required"`
+	Ignored  string   `json:"-" validate:"max=1"`
+	internal string   `validate:"required"`
+}
+
+func validRequest() testRequest {
+	comment := "ok"
+	return testRequest{
+		Name:     "Anna",
+		Year:     1999,
+		Count:    1,
+		Comment:  &comment,
+		Untagged: "set",
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestStruct(t *testing.T) {
+	nextYear := time.Now().Year() + 1
+	yearMessage := fmt.Sprintf("must be between %d and %d", MinYear, nextYear)
+
+	tests := []struct {
+		name   string
+		change func(*testRequest)
+		want   []FieldError
+	}{
+		{"valid", func(r *testRequest) {}, nil},
+		{"required string", func(r *testRequest) { r.Name = "" }, []FieldError{{"name", "is required"}}},
+		{"blank string", func(r *testRequest) { r.Name = "   " }, []FieldError{{"name", "is required"}}},
+		{"string too short", func(r *testRequest) { r.Name = "A" }, []FieldError{{"name", "must be at least 2 characters long"}}},
+		{"string too long", func(r *testRequest) { r.Name = "Annabel" }, []FieldError{{"name", "must be at most 5 characters long"}}},
+		{"length counts runes", func(r *testRequest) { r.Name = "Ёлка" }, nil},
+		{"empty optional email", func(r *testRequest) { r.Email = "" }, nil},
+		{"valid email", func(r *testRequest) { r.Email = "anna@example.com" }, nil},
+		{"invalid email", func(r *testRequest) { r.Email = "anna" }, []FieldError{{"email", "must be a valid email address"}}},
+		{"email with display name", func(r *testRequest) { r.Email = "Anna <anna@example.com>" },
+			[]FieldError{{"email", "must be a valid email address"}}},
+		{"next year", func(r *testRequest) { r.Year = nextYear }, nil},
+		{"year too late", func(r *testRequest) { r.Year = nextYear + 1 }, []FieldError{{"year", yearMessage}}},
+		{"year zero", func(r *testRequest) { r.Year = 0 }, []FieldError{{"year", yearMessage}}},
+		{"number too small", func(r *testRequest) { r.Count = 0 }, []FieldError{{"count", "must be at least 1"}}},
+		{"number too large", func(r *testRequest) { r.Count = 11 }, []FieldError{{"count", "must be at most 10"}}},
+		{"too many items", func(r *testRequest) { r.Tags = []string{"a", "b", "c"} },
+			[]FieldError{{"tags", "must contain at most 2 items"}}},
+		{"optional pointer", func(r *testRequest) { r.Nickname = stringPtr("An") },
+			[]FieldError{{"nickname", "must be at least 3 characters long"}}},
+		{"empty optional pointer", func(r *testRequest) { r.Nickname = stringPtr("") }, nil},
+		{"required pointer", func(r *testRequest) { r.Comment = nil }, []FieldError{{"comment", "is required"}}},
+		{"required pointer to empty", func(r *testRequest) { r.Comment = stringPtr("") }, []FieldError{{"comment", "is required"}}},
+		{"field without json name", func(r *testRequest) { r.Untagged = "" }, []FieldError{{"Untagged", "is required"}}},
+		{"field hidden from json", func(r *testRequest) { r.Ignored = "ab" }, []FieldError{{"Ignored", "must be at most 1 characters long"}}},
+		{"every violation", func(r *testRequest) { r.Name = ""; r.Count = 0 },
+			[]FieldError{{"name", "is required"}, {"count", "must be at least 1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := validRequest()
+			tt.change(&request)
+
+			if got := Struct(&request); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructAcceptsValuesAndIgnoresOthers(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  int
+	}{
+		{"struct value", testRequest{}, 5},
+		{"nil pointer", (*testRequest)(nil), 0},
+		{"not a struct", "text", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Struct(tt.value); len(got) != tt.want {
+				t.Errorf("Struct returned %d errors, want %d: %v", len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStructPanicsOnBadRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"unknown rule", struct {
+			A string `validate:"uuid"`
+		}{}},
+		{"invalid bound", struct {
+			A string `validate:"min=x"`
+		}{}},
+		{"unsupported kind", struct {
+			A bool `validate:"max=1"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			Struct(tt.value)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+CREATE TABLE roles (
+                       id UUID PRIMARY KEY,
+                       name VARCHAR(64) NOT NULL UNIQUE,
+                       description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE permissions (
+                             code VARCHAR(64) PRIMARY KEY,
+                             description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+                                  role_id UUID REFERENCES roles(id) ON DELETE CASCADE,
+                                  permission_code VARCHAR(64) REFERENCES permissions(code) ON DELETE CASCADE,
+                                  PRIMARY KEY (role_id, permission_code)
+);
+
+CREATE TABLE user_roles (
+                            user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                            role_id UUID REFERENCES roles(id) ON DELETE CASCADE,
+                            granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                            granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                            PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role ON user_roles(role_id);
+
+INSERT INTO permissions (code, description) VALUES
+    ('books:read', 'Read the catalog'),
+    ('books:write', 'Create, update and delete books'),
+    ('users:read', 'Read user accounts'),
+    ('users:write', 'Create, update and delete user accounts'),
+    ('roles:manage', 'Grant and revoke roles');
+
+INSERT INTO roles (id, name, description) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'admin', 'Full access to the catalog and accounts'),
+    ('00000000-0000-0000-0000-000000000002', 'librarian', 'Edits the catalog'),
+    ('00000000-0000-0000-0000-000000000003', 'auditor', 'Reads everything, changes nothing'),
+    ('00000000-0000-0000-0000-000000000004', 'member', 'Regular library member');
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT '00000000-0000-0000-0000-000000000001', code FROM permissions;
+
+INSERT INTO role_permissions (role_id, permission_code) VALUES
+    ('00000000-0000-0000-0000-000000000002', 'books:read'),
+    ('00000000-0000-0000-0000-000000000002', 'books:write'),
+    ('00000000-0000-0000-0000-000000000003', 'books:read'),
+    ('00000000-0000-0000-0000-000000000003', 'users:read'),
+    ('00000000-0000-0000-0000-000000000004', 'books:read');
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT id, '00000000-0000-0000-0000-000000000001' FROM users WHERE is_admin = TRUE;
@@ -0,0 +1,11 @@
+CREATE TABLE security_audit_log (
+                                    id UUID PRIMARY KEY,
+                                    event_type VARCHAR(64) NOT NULL,
+                                    actor_id UUID,
+                                    target_user_id UUID,
+                                    details JSONB NOT NULL DEFAULT '{}',
+                                    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_security_audit_target ON security_audit_log(target_user_id);
+CREATE INDEX idx_security_audit_created ON security_audit_log(created_at);
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE user_tokens (
+                             token_hash VARCHAR(64) PRIMARY KEY,
+                             user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             purpose VARCHAR(32) NOT NULL,
+                             expires_at TIMESTAMPTZ NOT NULL,
+                             used_at TIMESTAMPTZ,
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_tokens_user ON user_tokens(user_id, purpose);
@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission_code = 'api_keys:manage';
+DELETE FROM permissions WHERE code = 'api_keys:manage';
+DROP TABLE IF EXISTS api_keys;
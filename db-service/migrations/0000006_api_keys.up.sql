@@ -0,0 +1,19 @@
+CREATE TABLE api_keys (
+                          id UUID PRIMARY KEY,
+                          name VARCHAR(255) NOT NULL,
+                          prefix VARCHAR(16) NOT NULL UNIQUE,
+                          key_hash VARCHAR(64) NOT NULL,
+                          permissions TEXT[] NOT NULL DEFAULT '{}',
+                          created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          expires_at TIMESTAMPTZ,
+                          last_used_at TIMESTAMPTZ,
+                          rotated_at TIMESTAMPTZ,
+                          revoked_at TIMESTAMPTZ
+);
+
+INSERT INTO permissions (code, description) VALUES
+    ('api_keys:manage', 'Create, rotate and revoke API keys');
+
+INSERT INTO role_permissions (role_id, permission_code) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'api_keys:manage');
@@ -0,0 +1,11 @@
+CREATE TABLE user_identities (
+                                 provider VARCHAR(64) NOT NULL,
+                                 subject VARCHAR(255) NOT NULL,
+                                 user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 email VARCHAR(255) NOT NULL DEFAULT '',
+                                 created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                 last_login_at TIMESTAMPTZ,
+                                 PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
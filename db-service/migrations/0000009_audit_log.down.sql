@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission_code = 'audit:read';
+DELETE FROM permissions WHERE code = 'audit:read';
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,43 @@
+CREATE TABLE audit_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           occurred_at TIMESTAMPTZ NOT NULL,
+                           actor_id UUID,
+                           api_key_id UUID,
+                           action VARCHAR(128) NOT NULL,
+                           entity_type VARCHAR(64) NOT NULL DEFAULT '',
+                           entity_id VARCHAR(64) NOT NULL DEFAULT '',
+                           changes JSONB NOT NULL DEFAULT '{}',
+                           method VARCHAR(16) NOT NULL DEFAULT '',
+                           route VARCHAR(255) NOT NULL DEFAULT '',
+                           status INTEGER NOT NULL DEFAULT 0,
+                           ip VARCHAR(64) NOT NULL DEFAULT '',
+                           request_id VARCHAR(128) NOT NULL DEFAULT '',
+                           prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+                           hash VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_occurred ON audit_log(occurred_at);
+CREATE INDEX idx_audit_log_request ON audit_log(request_id);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO permissions (code, description) VALUES
+    ('audit:read', 'Read and verify the audit log');
+
+INSERT INTO role_permissions (role_id, permission_code) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'audit:read'),
+    ('00000000-0000-0000-0000-000000000003', 'audit:read');
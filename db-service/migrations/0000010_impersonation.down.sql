@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission_code = 'users:impersonate';
+DELETE FROM permissions WHERE code = 'users:impersonate';
+ALTER TABLE audit_log DROP COLUMN on_behalf_of;
@@ -0,0 +1,9 @@
+ALTER TABLE audit_log ADD COLUMN on_behalf_of UUID;
+
+CREATE INDEX idx_audit_log_on_behalf_of ON audit_log(on_behalf_of);
+
+INSERT INTO permissions (code, description) VALUES
+    ('users:impersonate', 'Act as another user for support');
+
+INSERT INTO role_permissions (role_id, permission_code) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'users:impersonate');
@@ -0,0 +1,24 @@
+CREATE TABLE login_history (
+                               id UUID PRIMARY KEY,
+                               user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               ip VARCHAR(64) NOT NULL DEFAULT '',
+                               network VARCHAR(64) NOT NULL DEFAULT '',
+                               user_agent VARCHAR(512) NOT NULL DEFAULT '',
+                               fingerprint VARCHAR(64) NOT NULL,
+                               suspicious BOOLEAN NOT NULL DEFAULT FALSE,
+                               reasons TEXT[] NOT NULL DEFAULT '{}',
+                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_history_user ON login_history(user_id, created_at);
+CREATE INDEX idx_login_history_network ON login_history(user_id, network);
+
+CREATE TABLE user_devices (
+                              user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                              fingerprint VARCHAR(64) NOT NULL,
+                              user_agent VARCHAR(512) NOT NULL DEFAULT '',
+                              last_ip VARCHAR(64) NOT NULL DEFAULT '',
+                              first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                              last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                              PRIMARY KEY (user_id, fingerprint)
+);
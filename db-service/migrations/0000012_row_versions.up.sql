@@ -0,0 +1,3 @@
+ALTER TABLE books ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
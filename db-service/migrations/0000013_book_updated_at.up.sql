@@ -0,0 +1 @@
+ALTER TABLE books ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
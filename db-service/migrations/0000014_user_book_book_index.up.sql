@@ -0,0 +1 @@
+CREATE INDEX idx_user_book_book ON user_book(book_id);
@@ -0,0 +1 @@
+ALTER TABLE user_roles ADD COLUMN granted_via VARCHAR(64);
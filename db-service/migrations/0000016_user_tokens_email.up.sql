@@ -0,0 +1 @@
+ALTER TABLE user_tokens ADD COLUMN email VARCHAR(255) NOT NULL DEFAULT '';
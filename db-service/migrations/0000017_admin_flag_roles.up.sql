@@ -0,0 +1,9 @@
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r
+WHERE u.is_admin = TRUE AND r.name = 'admin'
+ON CONFLICT (user_id, role_id) DO NOTHING;
+
+UPDATE users SET is_admin = TRUE, version = version + 1
+WHERE is_admin = FALSE AND id IN (
+    SELECT ur.user_id FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name = 'admin'
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_book_updated_at;
+DROP TABLE book_catalog;
@@ -0,0 +1,8 @@
+CREATE TABLE book_catalog (
+                              id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+                              deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO book_catalog DEFAULT VALUES;
+
+CREATE INDEX idx_book_updated_at ON books(updated_at);